@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/moby/go-archive"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/seqrateam/seqra/internal/bundle"
+	"github.com/seqrateam/seqra/internal/container_run"
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/utils"
+	"github.com/seqrateam/seqra/internal/utils/log"
+	"github.com/seqrateam/seqra/internal/version"
+)
+
+var bundleOutputPath string
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Export and import seqra artifacts for offline use",
+	Long: `Export the analyzer and autobuilder images, the bundled ruleset and the analyzer and autobuilder jars into a single archive
+on a machine with internet access, and import it on machines without it. Then run seqra with --offline.`,
+}
+
+var bundleExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export images and artifacts into a bundle",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		absBundlePath := log.AbsPathOrExit(bundleOutputPath, "output")
+
+		tempDirName, err := os.MkdirTemp("", "seqra-bundle-*")
+		if err != nil {
+			logrus.Fatalf("Failed to create temporary directory: %s", err)
+		}
+		defer func() {
+			_ = os.RemoveAll(tempDirName)
+		}()
+
+		rulesPath := ensureBundledRuleset()
+		autobuilderJarPath := ensureAutobuilderJar()
+		// The analyzer jar is used by --scan-type native
+		analyzerJarPath := ensureAnalyzerJar()
+
+		analyzerImageLink := utils.GetAnalyzerImageLink()
+		autobuilderImageLink := utils.GetAutobuilderImageLink()
+		container_run.PullGhcrImage(analyzerImageLink)
+		container_run.PullGhcrImage(autobuilderImageLink)
+
+		images := []container_run.ExportedImage{
+			exportedImage(analyzerImageLink, utils.GetImageLink(globals.Config.Analyzer.Version, utils.GetAnalyzerRepository())),
+			exportedImage(autobuilderImageLink, utils.GetImageLink(globals.Config.Autobuilder.Version, utils.GetAutobuilderRepository())),
+		}
+
+		logrus.Info()
+		logrus.Info("=== Export bundle ===")
+		imagesPath := filepath.Join(tempDirName, bundle.ImagesFile)
+		logrus.Infof("Save images: %s, %s", images[0].Reference, images[1].Reference)
+		if err := saveImagesToFile(images, imagesPath); err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to save images: %s", err)
+		}
+
+		rulesTarPath := filepath.Join(tempDirName, bundle.RulesFile)
+		if err := tarDirToFile(rulesPath, rulesTarPath); err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to archive ruleset: %s", err)
+		}
+
+		manifest := bundle.Manifest{
+			SeqraVersion:       version.Version,
+			AnalyzerVersion:    globals.Config.Analyzer.Version,
+			AutobuilderVersion: globals.Config.Autobuilder.Version,
+			RulesVersion:       globals.RulesBindVersion,
+			Images:             images,
+		}
+		files := map[string]string{
+			bundle.ImagesFile:      imagesPath,
+			bundle.RulesFile:       rulesTarPath,
+			bundle.AutobuilderFile: autobuilderJarPath,
+			bundle.AnalyzerFile:    analyzerJarPath,
+		}
+		if err := bundle.Write(absBundlePath, manifest, files); err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to write bundle: %s", err)
+		}
+		logrus.Infof("Bundle written to: %s", absBundlePath)
+	},
+}
+
+var bundleImportCmd = &cobra.Command{
+	Use:   "import bundle",
+	Short: "Import images and artifacts from a bundle",
+	Args:  cobra.ExactArgs(1),
+	Long: `Load images from the bundle into the local Docker daemon and put the ruleset and the analyzer and autobuilder jars into ~/.seqra
+
+Arguments:
+  bundle  - Path to a bundle created by "seqra bundle export" (required)
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		absBundlePath := log.AbsPathOrExit(args[0], "bundle")
+
+		tempDirName, err := os.MkdirTemp("", "seqra-bundle-*")
+		if err != nil {
+			logrus.Fatalf("Failed to create temporary directory: %s", err)
+		}
+		defer func() {
+			_ = os.RemoveAll(tempDirName)
+		}()
+
+		logrus.Info()
+		logrus.Info("=== Import bundle ===")
+		manifest, err := bundle.Read(absBundlePath, tempDirName)
+		if err != nil {
+			logrus.Fatalf("Invalid bundle %s: %s", absBundlePath, err)
+		}
+		logrus.Infof("Bundle created by seqra %s", manifest.SeqraVersion)
+
+		if err := loadImagesFromFile(filepath.Join(tempDirName, bundle.ImagesFile), manifest.Images); err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to load images: %s", err)
+		}
+		for _, exported := range manifest.Images {
+			logrus.Infof("Loaded image: %s", exported.Reference)
+		}
+
+		rulesPath, err := utils.GetRulesPath(manifest.RulesVersion)
+		if err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to construct path to the ruleset: %s", err)
+		}
+		unpackedRulesPath := filepath.Join(tempDirName, "rules")
+		if err := untarFileToDir(filepath.Join(tempDirName, bundle.RulesFile), unpackedRulesPath); err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to unpack ruleset: %s", err)
+		}
+		if err := utils.InstallCachedDir(unpackedRulesPath, rulesPath); err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to install ruleset: %s", err)
+		}
+		logrus.Infof("Installed ruleset: %s", rulesPath)
+
+		autobuilderJarPath, err := utils.GetAutobuilderJarPath(manifest.AutobuilderVersion)
+		if err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to construct path to the autobuilder: %s", err)
+		}
+		if err := utils.InstallCachedFile(filepath.Join(tempDirName, bundle.AutobuilderFile), autobuilderJarPath); err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to install autobuilder: %s", err)
+		}
+		logrus.Infof("Installed autobuilder: %s", autobuilderJarPath)
+
+		// Bundles of earlier seqra versions have no analyzer jar
+		if _, ok := manifest.Checksums[bundle.AnalyzerFile]; ok {
+			analyzerJarPath, err := utils.GetAnalyzerJarPath(manifest.AnalyzerVersion)
+			if err != nil {
+				logrus.Fatalf("Unexpected error occurred while trying to construct path to the analyzer: %s", err)
+			}
+			if err := utils.InstallCachedFile(filepath.Join(tempDirName, bundle.AnalyzerFile), analyzerJarPath); err != nil {
+				logrus.Fatalf("Unexpected error occurred while trying to install analyzer: %s", err)
+			}
+			logrus.Infof("Installed analyzer: %s", analyzerJarPath)
+		} else {
+			logrus.Warn("Bundle has no analyzer jar, --scan-type native needs network access to download it")
+		}
+
+		if manifest.AnalyzerVersion != globals.Config.Analyzer.Version ||
+			manifest.AutobuilderVersion != globals.Config.Autobuilder.Version ||
+			manifest.RulesVersion != globals.RulesBindVersion {
+			logrus.Warnf("Bundle versions differ from the versions used by this seqra: analyzer %s, autobuilder %s, rules %s",
+				manifest.AnalyzerVersion, manifest.AutobuilderVersion, manifest.RulesVersion)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleExportCmd, bundleImportCmd)
+
+	bundleExportCmd.Flags().StringVarP(&bundleOutputPath, "output", "o", "seqra-bundle.tar", "Path to the bundle")
+}
+
+// exportedImage saves images under their tag, and remembers the digest reference they are used with
+func exportedImage(imageLink, taggedImageLink string) container_run.ExportedImage {
+	exported := container_run.ExportedImage{Reference: taggedImageLink}
+	if imageLink != taggedImageLink {
+		exported.PinnedReference = imageLink
+	}
+	return exported
+}
+
+func saveImagesToFile(images []container_run.ExportedImage, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+	if err := container_run.SaveImages(images, out); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func loadImagesFromFile(path string, images []container_run.ExportedImage) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	return container_run.LoadImages(in, images)
+}
+
+func tarDirToFile(srcDir, path string) error {
+	tarStream, err := archive.TarWithOptions(srcDir, &archive.TarOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tarStream.Close()
+	}()
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+	if _, err := io.Copy(out, tarStream); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func untarFileToDir(path, destDir string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+	return utils.ExtractTar(tar.NewReader(in), "", destDir, true)
+}
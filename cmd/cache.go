@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/seqrateam/seqra/internal/container_run"
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/home_cache"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+var cacheImages bool
+var cacheKeepLogs int
+var cacheDryRun bool
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and prune seqra home",
+	Long: `Inspect and prune artifacts accumulated in ~/.seqra: analyzer and autobuilder jars, rulesets, cached project models and logs.
+With --images seqra Docker images from the local Docker daemon are handled too.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached artifacts with their versions and sizes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		artifacts := listArtifactsOrExit()
+		logrus.Info("=== Seqra home ===")
+		for _, artifact := range artifacts {
+			logrus.Infof("%-12s %-40s %10s  %s", artifact.Type, artifact.Version, units.HumanSize(float64(artifact.Size)), artifact.Path)
+		}
+
+		if cacheImages {
+			images := listImagesOrExit()
+			logrus.Info()
+			logrus.Info("=== Docker images ===")
+			for _, seqraImage := range images {
+				state := "stale"
+				if seqraImage.Current {
+					state = "current"
+				}
+				for _, reference := range seqraImage.References {
+					logrus.Infof("%-8s %10s  %s", state, units.HumanSize(float64(seqraImage.Size)), reference)
+				}
+			}
+		}
+	},
+}
+
+var cacheSizeCmd = &cobra.Command{
+	Use:   "size",
+	Short: "Print the size of cached artifacts by type",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		artifacts := listArtifactsOrExit()
+		sizes := make(map[home_cache.ArtifactType]int64)
+		counts := make(map[home_cache.ArtifactType]int)
+		var total int64
+		for _, artifact := range artifacts {
+			sizes[artifact.Type] += artifact.Size
+			counts[artifact.Type]++
+			total += artifact.Size
+		}
+
+		for _, artifactType := range home_cache.ArtifactTypes {
+			logrus.Infof("%-12s %4d items %10s", artifactType, counts[artifactType], units.HumanSize(float64(sizes[artifactType])))
+		}
+
+		if cacheImages {
+			var imagesSize int64
+			images := listImagesOrExit()
+			for _, seqraImage := range images {
+				imagesSize += seqraImage.Size
+			}
+			logrus.Infof("%-12s %4d items %10s", "image", len(images), units.HumanSize(float64(imagesSize)))
+			total += imagesSize
+		}
+
+		logrus.Infof("Total: %s", units.HumanSize(float64(total)))
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove artifacts not used by the current seqra version",
+	Long: `Remove analyzer and autobuilder jars and rulesets of versions other than the current ones,
+leftovers of downloads interrupted more than an hour ago and all but the newest logs.
+With --images stale seqra Docker images are removed from the local Docker daemon.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		artifacts := listArtifactsOrExit()
+		stale := home_cache.Stale(artifacts, globals.Config.Analyzer.Version, globals.Config.Autobuilder.Version, globals.RulesBindVersion, cacheKeepLogs, globals.LogPath)
+		removeArtifacts(stale)
+
+		if cacheImages {
+			var staleImages []container_run.SeqraImage
+			for _, seqraImage := range listImagesOrExit() {
+				if !seqraImage.Current {
+					staleImages = append(staleImages, seqraImage)
+				}
+			}
+			removeImages(staleImages)
+		}
+	},
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove all cached artifacts",
+	Long: `Remove all analyzer and autobuilder jars, rulesets, cached project models and logs from ~/.seqra.
+With --images all seqra Docker images are removed from the local Docker daemon.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var artifacts []home_cache.Artifact
+		for _, artifact := range listArtifactsOrExit() {
+			// The log of the current run is still written
+			if artifact.Path != globals.LogPath {
+				artifacts = append(artifacts, artifact)
+			}
+		}
+		removeArtifacts(artifacts)
+
+		if cacheImages {
+			removeImages(listImagesOrExit())
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd, cacheSizeCmd, cachePruneCmd, cacheCleanCmd)
+
+	cacheCmd.PersistentFlags().BoolVar(&cacheImages, "images", false, "Also handle seqra images in the local Docker daemon")
+
+	cachePruneCmd.Flags().IntVar(&cacheKeepLogs, "keep-logs", 10, "Number of the newest log files to keep")
+	cachePruneCmd.Flags().BoolVar(&cacheDryRun, "dry-run", false, "Only print what would be removed")
+	cacheCleanCmd.Flags().BoolVar(&cacheDryRun, "dry-run", false, "Only print what would be removed")
+}
+
+func listArtifactsOrExit() []home_cache.Artifact {
+	artifacts, err := home_cache.List()
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to list seqra home: %s", err)
+	}
+	return artifacts
+}
+
+func listImagesOrExit() []container_run.SeqraImage {
+	currentImageLinks := []string{
+		utils.GetAnalyzerImageLink(),
+		utils.GetAutobuilderImageLink(),
+	}
+	images, err := container_run.ListSeqraImages(currentImageLinks)
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to list Docker images: %s", err)
+	}
+	return images
+}
+
+func removeArtifacts(artifacts []home_cache.Artifact) {
+	var freed int64
+	for _, artifact := range artifacts {
+		if cacheDryRun {
+			logrus.Infof("Would remove %s: %s", artifact.Type, artifact.Path)
+			continue
+		}
+		if err := home_cache.Remove(artifact); err != nil {
+			logrus.Errorf("Failed to remove %s: %s", artifact.Path, err)
+			continue
+		}
+		logrus.Infof("Removed %s: %s", artifact.Type, artifact.Path)
+		freed += artifact.Size
+	}
+	if !cacheDryRun {
+		logrus.Infof("Freed: %s", units.HumanSize(float64(freed)))
+	}
+}
+
+func removeImages(images []container_run.SeqraImage) {
+	for _, seqraImage := range images {
+		if cacheDryRun {
+			logrus.Infof("Would remove image: %v", seqraImage.References)
+			continue
+		}
+		if err := container_run.RemoveImage(seqraImage.ID); err != nil {
+			logrus.Errorf("Failed to remove image %v: %s", seqraImage.References, err)
+			continue
+		}
+		logrus.Infof("Removed image: %v", seqraImage.References)
+	}
+}
@@ -3,20 +3,48 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-units"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
+	"github.com/seqrateam/seqra/internal/build_env"
+	"github.com/seqrateam/seqra/internal/build_failure"
 	"github.com/seqrateam/seqra/internal/container_run"
+	"github.com/seqrateam/seqra/internal/failure"
 	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/ignore"
+	"github.com/seqrateam/seqra/internal/jdk"
+	"github.com/seqrateam/seqra/internal/model_archive"
+	"github.com/seqrateam/seqra/internal/phase"
 	"github.com/seqrateam/seqra/internal/utils"
 	"github.com/seqrateam/seqra/internal/utils/log"
 )
 
+const defaultNativeAutobuilderHeap = "1G"
+
+// dockerResultDir is where the autobuilder container writes the project model
+const dockerResultDir = "/data/build"
+
+// dockerPreviousModelDir is where the autobuilder container reads the model of an incremental build from
+const dockerPreviousModelDir = "/data/previous-model"
+
+// nativeWaitDelay is how long the output of a stopped native process is awaited
+const nativeWaitDelay = 10 * time.Second
+
+// nativeCompileTailLines is the number of last output lines of a native compile used to diagnose a failure
+const nativeCompileTailLines = 200
+
 var OutputProjectModelPath string
 var ProjectPath string
 
@@ -24,31 +52,85 @@ var ProjectPath string
 var compileCmd = &cobra.Command{
 	Use:   "compile project",
 	Short: "Compile your Java project",
-	Args:  cobra.MinimumNArgs(1), // require at least one argument
+	Args: func(cmd *cobra.Command, args []string) error {
+		if ArtifactsPath != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args) // require at least one argument
+	},
 	Long: `This command takes a required path to the project, automatically detects Java build system, modules and dependencies and compile project model.
+With --from-artifacts the project model is built from pre-built JARs, WARs and class directories instead.
+With --archive the model is also packed into a relocatable archive, which "seqra scan" accepts on another machine.
 
 Arguments:
-  project  - Path to a project to compile (required)
+  project  - Path to a project to compile (required unless --from-artifacts is used)
 `,
 	Annotations: map[string]string{"PrintConfig": "true"},
 	PreRun: func(cmd *cobra.Command, args []string) {
 		bindCompileTypeFlag(cmd)
+		bindCompileTimeoutFlag(cmd)
+		bindJavaHomeFlag(cmd)
+		bindBuildHintFlags(cmd)
+		bindExcludeFlag(cmd)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		ProjectPath = args[0]
-
-		projectRoot := filepath.Clean(ProjectPath)
-		absProjectRoot := log.AbsPathOrExit(projectRoot, "project path")
+		if OutputProjectModelPath == "" && ArchivePath == "" {
+			logrus.Fatalf(`Either the "output" or the "archive" flag is required`)
+		}
 
-		outputProjectModelPath := filepath.Clean(OutputProjectModelPath)
-		absOutputProjectModelPath := log.AbsPathOrExit(outputProjectModelPath, "output")
+		var absOutputProjectModelPath string
+		var tempDirName string
+		if OutputProjectModelPath != "" {
+			absOutputProjectModelPath = log.AbsPathOrExit(filepath.Clean(OutputProjectModelPath), "output")
+		} else {
+			var err error
+			tempDirName, err = os.MkdirTemp("", "seqra-*")
+			if err != nil {
+				logrus.Fatalf("Failed to create temporary directory: %s", err)
+			}
+			absOutputProjectModelPath = tempDirName + "/project-model"
+			tempDir := tempDirName
+			untrackTempDir := failure.Track("temporary project model", absOutputProjectModelPath, func() error {
+				return os.RemoveAll(tempDir)
+			})
+			defer func() {
+				untrackTempDir()
+				_ = os.RemoveAll(tempDir)
+			}()
+		}
+		var absArchivePath string
+		if ArchivePath != "" {
+			absArchivePath = log.AbsPathOrExit(ArchivePath, "archive")
+		}
 
+		var absProjectRoot string
 		logrus.Info()
-		logrus.Infof("=== Compile only mode ===")
-		logrus.Infof("Project: %s", absProjectRoot)
-		logrus.Infof("Project model write to: %s", absOutputProjectModelPath)
+		if ArtifactsPath != "" {
+			absProjectRoot = log.AbsPathOrExit(ArtifactsPath, "from-artifacts")
+			logrus.Infof("=== Compile from artifacts mode ===")
+			logrus.Infof("Artifacts: %s", absProjectRoot)
+		} else {
+			ProjectPath = args[0]
+			absProjectRoot = log.AbsPathOrExit(filepath.Clean(ProjectPath), "project path")
+			logrus.Infof("=== Compile only mode ===")
+			logrus.Infof("Project: %s", absProjectRoot)
+		}
+		if tempDirName == "" {
+			logrus.Infof("Project model write to: %s", absOutputProjectModelPath)
+		}
+		if absArchivePath != "" {
+			logrus.Infof("Project model archive write to: %s", absArchivePath)
+		}
+
+		if ArtifactsPath != "" {
+			compileFromArtifacts(absProjectRoot, absOutputProjectModelPath)
+		} else {
+			compile(absProjectRoot, absOutputProjectModelPath, globals.Config.Compile.Type, nil)
+		}
 
-		compile(absProjectRoot, absOutputProjectModelPath, globals.Config.Compile.Type)
+		if absArchivePath != "" {
+			archiveProjectModel(absOutputProjectModelPath, absArchivePath, absProjectRoot)
+		}
 	},
 }
 
@@ -56,12 +138,91 @@ func init() {
 	rootCmd.AddCommand(compileCmd)
 
 	compileCmd.Flags().StringVarP(&OutputProjectModelPath, "output", "o", "", `Path to the result project model`)
-	_ = compileCmd.MarkFlagRequired("output")
+	compileCmd.Flags().StringVar(&ArchivePath, "archive", "", "Path to a relocatable project model archive ("+model_archive.Suffix+") to write")
 
 	compileCmd.Flags().StringVar(&globals.Config.Compile.Type, "compile-type", "docker", "Environment for run compile command (docker, native)")
+	compileCmd.Flags().DurationVar(&globals.Config.Compile.Timeout, "compile-timeout", 0, "Timeout for compile (default: no limit)")
+	compileCmd.Flags().StringVar(&globals.Config.Compile.JavaHome, "java-home", "", "JDK used by native compile (default: an installed JDK matching the project)")
+	compileCmd.Flags().StringSliceVar(&globals.Config.Exclude, "exclude", nil, "Project paths not to send to the container, in gitignore syntax (added to .seqraignore)")
+	addBuildHintFlags(compileCmd)
+	compileCmd.Flags().StringVar(&ArtifactsPath, "from-artifacts", "", "Directory with pre-built JARs, WARs or class directories to build the project model from")
+	compileCmd.Flags().StringSliceVar(&ArtifactSourcePaths, "sources", nil, "Source directory or sources JAR of the artifacts (*-sources.jar next to them are used automatically)")
+}
+
+var buildSystems = []string{"maven", "gradle", "ant", "bazel"}
+
+func addBuildHintFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&globals.Config.Compile.BuildSystem, "build-system", "", "Build system of the project ("+strings.Join(buildSystems, ", ")+"), detected by default")
+	cmd.Flags().StringSliceVar(&globals.Config.Compile.Goals, "build-goal", nil, "Maven goal or Gradle task to run instead of the default ones")
+	cmd.Flags().StringSliceVar(&globals.Config.Compile.MavenProfiles, "maven-profile", nil, "Maven profile to activate")
+	cmd.Flags().StringSliceVar(&globals.Config.Compile.GradleProperties, "gradle-property", nil, "Gradle project property as key=value")
+	cmd.Flags().StringSliceVar(&globals.Config.Compile.Subprojects, "subproject", nil, "Maven module or Gradle project to build, the whole project by default")
+	cmd.Flags().StringVar(&globals.Config.Compile.BuildCommand, "build-command", "", "Command which builds the project instead of the autobuilder, requires --classpath-file")
+	cmd.Flags().StringVar(&globals.Config.Compile.ClasspathFile, "classpath-file", "", "File with the project classpath relative to the project root, written by --build-command")
+}
+
+func bindBuildHintFlags(cmd *cobra.Command) {
+	_ = viper.BindPFlag("compile.build_system", cmd.Flags().Lookup("build-system"))
+	_ = viper.BindPFlag("compile.goals", cmd.Flags().Lookup("build-goal"))
+	_ = viper.BindPFlag("compile.maven_profiles", cmd.Flags().Lookup("maven-profile"))
+	_ = viper.BindPFlag("compile.gradle_properties", cmd.Flags().Lookup("gradle-property"))
+	_ = viper.BindPFlag("compile.subprojects", cmd.Flags().Lookup("subproject"))
+	_ = viper.BindPFlag("compile.build_command", cmd.Flags().Lookup("build-command"))
+	_ = viper.BindPFlag("compile.classpath_file", cmd.Flags().Lookup("classpath-file"))
 }
 
-func compile(absProjectRoot, absOutputProjectModelPath, compileType string) {
+// buildHintFlags validates the build hints of the config and turns them into autobuilder flags
+func buildHintFlags() []string {
+	hints := globals.Config.Compile
+	var flags []string
+
+	if hints.BuildSystem != "" {
+		if !slices.Contains(buildSystems, hints.BuildSystem) {
+			logrus.Fatalf("build-system must be one of %s", strings.Join(buildSystems, ", "))
+		}
+		flags = append(flags, "--build-system", hints.BuildSystem)
+	}
+	for _, goal := range hints.Goals {
+		flags = append(flags, "--build-goal", goal)
+	}
+	if len(hints.MavenProfiles) > 0 {
+		flags = append(flags, "--maven-profiles", strings.Join(hints.MavenProfiles, ","))
+	}
+	for _, property := range hints.GradleProperties {
+		if !strings.Contains(property, "=") {
+			logrus.Fatalf("Gradle property must be key=value: %s", property)
+		}
+		flags = append(flags, "--gradle-property", property)
+	}
+	for _, subproject := range hints.Subprojects {
+		flags = append(flags, "--subproject", subproject)
+	}
+
+	if hints.ClasspathFile != "" && filepath.IsAbs(hints.ClasspathFile) {
+		logrus.Fatalf("classpath-file must be relative to the project root: %s", hints.ClasspathFile)
+	}
+	if hints.BuildCommand != "" && hints.ClasspathFile == "" {
+		logrus.Fatalf("build-command requires classpath-file, the file with the classpath the command writes")
+	}
+	if (hints.BuildSystem == "ant" || hints.BuildSystem == "bazel") && hints.ClasspathFile == "" {
+		logrus.Fatalf("%s projects need build-command and classpath-file", hints.BuildSystem)
+	}
+	if hints.BuildCommand != "" {
+		flags = append(flags, "--build-command", hints.BuildCommand)
+	}
+	if hints.ClasspathFile != "" {
+		flags = append(flags, "--classpath-file", filepath.ToSlash(hints.ClasspathFile))
+	}
+	return flags
+}
+
+// previousModel lets the autobuilder rebuild only changed modules and take the others from a previous model
+type previousModel struct {
+	absPath string
+	modules []string
+}
+
+func compile(absProjectRoot, absOutputProjectModelPath, compileType string, previous *previousModel) {
 	if _, err := os.Stat(absOutputProjectModelPath); err == nil {
 		logrus.Fatalf("Output directory already exist: %s", absOutputProjectModelPath)
 	}
@@ -75,12 +236,32 @@ func compile(absProjectRoot, absOutputProjectModelPath, compileType string) {
 		appendFlags = append(appendFlags, "--verbosity=debug")
 	}
 
+	buildFlags := buildHintFlags()
+	if len(buildFlags) > 0 {
+		logrus.Infof("Build hints: %s", strings.Join(buildFlags, " "))
+		checkBuildHints(compileType, absProjectRoot, buildFlags)
+	}
+	appendFlags = append(appendFlags, buildFlags...)
+
+	if previous != nil && !supportsPreviousModel(compileType, absProjectRoot) {
+		logrus.Infof("Autobuilder %s can't rebuild only changed modules, all modules are rebuilt", globals.Config.Autobuilder.Version)
+		previous = nil
+	}
+	var absPreviousModelPath string
+	if previous != nil {
+		logrus.Infof("Rebuild changed modules: %s", strings.Join(previous.modules, ", "))
+		for _, module := range previous.modules {
+			appendFlags = append(appendFlags, "--subproject", module)
+		}
+		absPreviousModelPath = previous.absPath
+	}
+
 	logrus.Infof("Compile mode: %s", compileType)
 	switch compileType {
 	case "docker":
-		compileWithDocker(absOutputProjectModelPath, absProjectRoot, appendFlags)
+		compileWithDocker(absOutputProjectModelPath, absProjectRoot, absPreviousModelPath, appendFlags)
 	case "native":
-		compileWithNative(absOutputProjectModelPath, absProjectRoot, appendFlags)
+		compileWithNative(absOutputProjectModelPath, absProjectRoot, absPreviousModelPath, appendFlags)
 	default:
 		logrus.Fatalf("compile-type must be one of \"docker\", \"native\"")
 	}
@@ -90,16 +271,22 @@ func compile(absProjectRoot, absOutputProjectModelPath, compileType string) {
 	}
 }
 
-func compileWithDocker(absOutputProjectModelPath, absProjectRoot string, appendFlags []string) {
+func compileWithDocker(absOutputProjectModelPath, absProjectRoot, absPreviousModelPath string, appendFlags []string) {
 	autobuilderFlags := []string{
 		"--project-root-dir", "/data/project",
 		"--build", "portable",
-		"--result-dir", "/data/build",
+		"--result-dir", dockerResultDir,
 	}
 
 	autobuilderFlags = append(autobuilderFlags, appendFlags...)
 
 	hostConfig := &container.HostConfig{}
+	if network := globals.Config.Compile.Network; network != "" {
+		hostConfig.NetworkMode = container.NetworkMode(network)
+		if len(build_env.ProxyEnv()) == 0 {
+			logrus.Warnf("Compile runs in Docker network %s, but no HTTP_PROXY or HTTPS_PROXY is set, dependency downloads may fail", network)
+		}
+	}
 
 	// Get the current user's UID and GID
 	containerUID := fmt.Sprintf("%d", os.Getuid())
@@ -108,21 +295,92 @@ func compileWithDocker(absOutputProjectModelPath, absProjectRoot string, appendF
 	envCont := []string{"CONTAINER_UID=" + containerUID, "CONTAINER_GID=" + containerGID}
 
 	var copyToContainer = make(map[string]string)
+	var excludeFromCopy = make(map[string][]string)
+	if absPreviousModelPath != "" {
+		autobuilderFlags = append(autobuilderFlags, "--previous-model", dockerPreviousModelDir)
+		copyToContainer[absPreviousModelPath] = dockerPreviousModelDir
+	}
 	copyToContainer[absProjectRoot] = "/data/project"
+	if container_run.UseBindMounts() {
+		// The build writes its outputs into the project, so the project is copied into a volume
+		// even in bind mode, instead of exposing the host directory to the build read-write
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:   mount.TypeVolume,
+			Target: "/data/project",
+		})
+	}
+	excludes := loadExcludes(absProjectRoot)
+	if !excludes.Empty() {
+		excludeFromCopy[absProjectRoot] = excludes.Patterns(filepath.Base(absProjectRoot))
+		logExcludedSize(excludes, absProjectRoot)
+	}
+
+	buildEnvDir, err := os.MkdirTemp("", "seqra-build-env-*")
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to create temporary directory: %s", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(buildEnvDir)
+	}()
+
+	dependencyCache, err := build_env.ForDependencyCache(buildEnvDir)
+	if err != nil {
+		logrus.Fatalf("Failed to prepare dependency cache: %s", err)
+	}
+	buildEnv, err := build_env.ForContainer(buildEnvDir, func(path string) ([]byte, error) {
+		return container_run.ReadImageFile(utils.GetAutobuilderImageLink(), path)
+	})
+	if err != nil {
+		logrus.Fatalf("Failed to prepare build environment: %s", err)
+	}
+	for _, setup := range []*build_env.Setup{dependencyCache, buildEnv} {
+		envCont = append(envCont, setup.Env...)
+		hostConfig.Mounts = append(hostConfig.Mounts, setup.Mounts...)
+		for hostPath, containerPath := range setup.Files {
+			copyToContainer[hostPath] = containerPath
+		}
+	}
+	// JAVA_TOOL_OPTIONS also reaches JVMs of Maven and Gradle started by the autobuilder
+	envCont = utils.AppendJavaToolOptions(envCont, utils.JavaOptions(globals.Config.Autobuilder.Heap, globals.Config.Autobuilder.JavaOptions))
 
 	var copyFromContainer = make(map[string]string)
-	copyFromContainer["/data/build"] = absOutputProjectModelPath
+	copyFromContainer[dockerResultDir] = absOutputProjectModelPath
+
+	autobuilderImageLink := utils.GetAutobuilderImageLink()
+	task := phase.Begin("Compile", "compile.timeout", globals.Config.Compile.Timeout)
+	defer task.End()
+	container_run.RunGhcrContainer(task, autobuilderImageLink, autobuilderFlags, envCont, hostConfig, copyToContainer, copyFromContainer, excludeFromCopy)
+}
 
-	autobuilderImageLink := utils.GetImageLink(globals.Config.Autobuilder.Version, globals.AutobuilderDocker)
-	container_run.RunGhcrContainer("Compile", autobuilderImageLink, autobuilderFlags, envCont, hostConfig, copyToContainer, copyFromContainer)
+func logExcludedSize(excludes *ignore.Matcher, absProjectRoot string) {
+	if !logrus.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+	files, size, err := excludes.ExcludedSize(absProjectRoot)
+	if err != nil {
+		logrus.Debugf("Can't compute size of excluded files: %s", err)
+		return
+	}
+	logrus.Debugf("Excluded from copy: %d files, %s", files, units.HumanSize(float64(size)))
 }
 
-func compileWithNative(absOutputProjectModelPath, absProjectRoot string, appendFlags []string) {
+// ensureAutobuilderJar downloads the autobuilder jar if it isn't present in seqra home yet
+func ensureAutobuilderJar() string {
 	autobuilderJarPath, err := utils.GetAutobuilderJarPath(globals.Config.Autobuilder.Version)
 	if err != nil {
 		logrus.Fatalf("Unexpected error occurred while trying to construct path to the autobuilder: %s", err)
 	}
 
+	if _, err := os.Stat(autobuilderJarPath); err == nil {
+		pinnedDigest, _ := globals.PinnedArtifactDigest(globals.AutobuilderRepoName, globals.Config.Autobuilder.Version, globals.AutobuilderAssetName)
+		if err := utils.VerifyCachedFile(autobuilderJarPath, pinnedDigest); err != nil {
+			logrus.Warnf("Cached autobuilder is damaged and will be downloaded again: %s", err)
+			if err := utils.RemoveCachedArtifact(autobuilderJarPath); err != nil {
+				logrus.Fatalf("Unexpected error occurred while trying to remove damaged autobuilder: %s", err)
+			}
+		}
+	}
+
 	if _, err := os.Stat(autobuilderJarPath); errors.Is(err, os.ErrNotExist) {
 		err := utils.DownloadGithubReleaseAsset(globals.RepoOwner, globals.AutobuilderRepoName, globals.Config.Autobuilder.Version, globals.AutobuilderAssetName, autobuilderJarPath, globals.Config.Github.Token)
 		if err != nil {
@@ -130,26 +388,176 @@ func compileWithNative(absOutputProjectModelPath, absProjectRoot string, appendF
 		}
 	}
 
-	autobuilderCommand := []string{
-		"-Xmx1G",
+	return autobuilderJarPath
+}
+
+// projectJDKs memoizes findProjectJDK, the JDK is printed with the config and used later by the compile
+var projectJDKs = make(map[string]projectJDK)
+
+type projectJDK struct {
+	jdk jdk.JDK
+	err error
+}
+
+// selectProjectJDK returns the configured JDK, or an installed one matching the Java release of the project
+func selectProjectJDK(absProjectRoot string) jdk.JDK {
+	selected, err := findProjectJDK(absProjectRoot)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	return selected
+}
+
+// findProjectJDK is selectProjectJDK returning an error when no JDK fits, the choice is logged once
+func findProjectJDK(absProjectRoot string) (jdk.JDK, error) {
+	if found, ok := projectJDKs[absProjectRoot]; ok {
+		return found.jdk, found.err
+	}
+	selected, err := discoverProjectJDK(absProjectRoot)
+	if err == nil {
+		logrus.Infof("Java: %s", selected)
+	}
+	projectJDKs[absProjectRoot] = projectJDK{jdk: selected, err: err}
+	return selected, err
+}
+
+func discoverProjectJDK(absProjectRoot string) (jdk.JDK, error) {
+	requirement := jdk.ProjectRequirement(absProjectRoot)
+	if requirement.Release != 0 {
+		logrus.Infof("Project Java release: %d (%s)", requirement.Release, requirement.Source)
+	}
+
+	if javaHome := globals.Config.Compile.JavaHome; javaHome != "" {
+		configured, err := jdk.FromHome(javaHome, "configured")
+		if err != nil {
+			return jdk.JDK{}, fmt.Errorf("invalid Java home: %w", err)
+		}
+		if configured.Version < requirement.Release {
+			logrus.Warnf("Project requires Java %d, but the configured JDK is Java %d", requirement.Release, configured.Version)
+		}
+		return configured, nil
+	}
+
+	jdks := jdk.Discover()
+	for _, found := range jdks {
+		logrus.Debugf("Found JDK: %s", found)
+	}
+	if len(jdks) == 0 {
+		return jdk.JDK{}, errors.New("no JDK found, install one or set it with --java-home")
+	}
+	selected, ok := jdk.Select(jdks, requirement.Release)
+	if !ok {
+		selected = jdks[0]
+		logrus.Warnf("Project requires Java %d, but no such JDK is installed, set it with --java-home", requirement.Release)
+	}
+	return selected, nil
+}
+
+// nativeCompileProject returns the project a native compile of the command would build, empty if there is none
+func nativeCompileProject(args []string) string {
+	if globals.Config.Compile.Type != "native" || len(args) == 0 || ArtifactsPath != "" || WorkspacePath != "" || OnlyScan {
+		return ""
+	}
+	absProjectRoot, err := filepath.Abs(args[0])
+	if err != nil || model_archive.IsArchive(absProjectRoot) {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(absProjectRoot, "project.yaml")); err == nil {
+		return ""
+	}
+	return absProjectRoot
+}
+
+func compileWithNative(absOutputProjectModelPath, absProjectRoot, absPreviousModelPath string, appendFlags []string) {
+	autobuilderJarPath := ensureAutobuilderJar()
+	if !loadExcludes(absProjectRoot).Empty() {
+		logrus.Warn("Native compile builds the project in place, excluded files are still seen by the build and only filtered from the findings")
+	}
+	projectJDK := selectProjectJDK(absProjectRoot)
+
+	heap := globals.Config.Autobuilder.Heap
+	if heap == "" {
+		heap = defaultNativeAutobuilderHeap
+	}
+	autobuilderCommand := utils.JavaOptions(heap, globals.Config.Autobuilder.JavaOptions)
+	autobuilderCommand = append(autobuilderCommand,
 		"-jar",
 		autobuilderJarPath,
 		"--project-root-dir", absProjectRoot,
 		"--build", "portable",
 		"--result-dir", absOutputProjectModelPath,
+	)
+	if absPreviousModelPath != "" {
+		autobuilderCommand = append(autobuilderCommand, "--previous-model", absPreviousModelPath)
 	}
 	autobuilderCommand = append(autobuilderCommand, appendFlags...)
 
-	cmd := exec.Command("java", autobuilderCommand...)
-	out, err := cmd.CombinedOutput()
-	logrus.Debugf("Autobuilder output:\n%s", string(out))
+	buildEnvDir, err := os.MkdirTemp("", "seqra-build-env-*")
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to create temporary directory: %s", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(buildEnvDir)
+	}()
 
+	buildEnv, err := build_env.ForNative(buildEnvDir, projectJDK.Home)
 	if err != nil {
-		logrus.Errorf("Autobuilder failed: %v", err)
+		logrus.Fatalf("Failed to prepare build environment: %s", err)
+	}
+
+	task := phase.Begin("Compile", "compile.timeout", globals.Config.Compile.Timeout)
+	defer task.End()
+
+	cmd := exec.CommandContext(task.Context(), projectJDK.Java(), autobuilderCommand...)
+	// Build tool daemons started by the autobuilder may keep the output open after it's killed
+	cmd.WaitDelay = nativeWaitDelay
+	cmd.Env = append(os.Environ(), projectJDK.Env()...)
+	cmd.Env = append(cmd.Env, buildEnv.Env...)
+
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	tail := build_failure.NewTail(nativeCompileTailLines)
+
+	if err := cmd.Start(); err != nil {
+		logrus.Fatalf("Failed to start autobuilder: %s", err)
+	}
+
+	progress := log.NewProgressLine("Compile", globals.Config.Quiet)
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		log.StreamLines(io.TeeReader(reader, tail), logrus.WithField("process", "autobuilder"), progress)
+	}()
+
+	err = cmd.Wait()
+	_ = writer.Close()
+	<-outputDone
+	progress.Stop()
+
+	if task.Expired() {
+		logrus.Fatalf("%s, check the full logs: %s", task.Error(), globals.LogPath)
+	}
+	if err == nil {
+		return
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		logrus.Fatalf("Autobuilder failed: %s", err)
 	}
 
-	exitCode := cmd.ProcessState.ExitCode()
-	if exitCode != 0 {
-		logrus.Errorf("Autobuilder exited with code %d", exitCode)
+	diagnosisRoot := absProjectRoot
+	if globals.Config.Compile.BuildSystem != "" || globals.Config.Compile.BuildCommand != "" {
+		// Build files aren't expected where the build system is given explicitly
+		diagnosisRoot = ""
+	}
+	diagnosis := build_failure.Diagnose(diagnosisRoot, tail.Lines())
+	logrus.Errorf("Autobuilder exited with code %d: %s", exitErr.ExitCode(), diagnosis.Cause)
+	for _, line := range diagnosis.Errors {
+		logrus.Errorf("  %s", line)
+	}
+	if diagnosis.Hint != "" {
+		logrus.Error(diagnosis.Hint)
 	}
+	logrus.Fatalf("Compile failed, check the full logs: %s", globals.LogPath)
 }
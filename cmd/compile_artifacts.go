@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/project_model"
+	"github.com/seqrateam/seqra/internal/utils/log"
+)
+
+var ArtifactsPath string
+var ArtifactSourcePaths []string
+
+// compileFromArtifacts builds a project model from pre-built JARs, WARs and class directories
+func compileFromArtifacts(absArtifactsPath, absOutputProjectModelPath string) {
+	if _, err := os.Stat(absOutputProjectModelPath); err == nil {
+		logrus.Fatalf("Output directory already exist: %s", absOutputProjectModelPath)
+	}
+
+	var absSourcePaths []string
+	for _, sourcePath := range ArtifactSourcePaths {
+		absSourcePaths = append(absSourcePaths, log.AbsPathOrExit(sourcePath, "sources"))
+	}
+
+	model, err := project_model.FromArtifacts(absArtifactsPath, absSourcePaths, absOutputProjectModelPath)
+	if err != nil {
+		_ = os.RemoveAll(absOutputProjectModelPath)
+		logrus.Fatalf("Failed to build project model from artifacts: %s", err)
+	}
+	logrus.Infof("Modules: %d, dependencies: %d", len(model.Modules), len(model.Dependencies))
+	for _, module := range model.Modules {
+		if len(module.Packages) == 0 {
+			logrus.Warnf("Module %s contains no classes", module.Classes[0])
+		}
+		logrus.Debugf("Module %s: %v", module.Classes[0], module.Packages)
+	}
+}
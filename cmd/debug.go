@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/seqrateam/seqra/internal/container_run"
+	"github.com/seqrateam/seqra/internal/debug_bundle"
+	"github.com/seqrateam/seqra/internal/failure"
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/home_cache"
+	"github.com/seqrateam/seqra/internal/utils"
+	"github.com/seqrateam/seqra/internal/utils/log"
+	"github.com/seqrateam/seqra/internal/version"
+)
+
+var debugBundleOutputPath string
+var debugBundleLogPath string
+var debugBundleIncludes []string
+
+// debugCmd represents the debug command
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Collect information for bug reports",
+}
+
+var debugBundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Zip logs, config and versions of the last failed run",
+	Long: `Collect the log of the last failed run (or the latest log), logs of containers kept with --keep-on-failure,
+the config, with secrets of the logs and the config redacted, and seqra, image and Docker versions into a zip archive to attach to an issue.
+Pass the same --config as for the failed run. Add files like the ruleset load errors with --include.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		absOutputPath := log.AbsPathOrExit(debugBundleOutputPath, "output")
+
+		record, err := failure.LastRecord()
+		if err != nil {
+			logrus.Warnf("Can't read the last failure: %s", err)
+		}
+
+		logPath := debugBundleLogPath
+		if logPath == "" && record != nil {
+			logPath = record.LogPath
+		}
+		if logPath == "" {
+			logPath = latestLogPath()
+		}
+
+		w, err := debug_bundle.Create(absOutputPath)
+		if err != nil {
+			logrus.Fatalf("Failed to create debug bundle: %s", err)
+		}
+
+		if err := w.AddText("versions.txt", versionLines()); err != nil {
+			logrus.Fatalf("Failed to write debug bundle: %s", err)
+		}
+		if err := w.AddConfig("config.json", viper.AllSettings()); err != nil {
+			logrus.Fatalf("Failed to write debug bundle: %s", err)
+		}
+
+		if logPath != "" {
+			if err := w.AddLog(debug_bundle.EntryName("logs", logPath), logPath); err != nil {
+				logrus.Warnf("Can't add log %s: %s", logPath, err)
+			}
+		} else {
+			logrus.Warn("No seqra log found")
+		}
+
+		if record != nil {
+			if err := w.AddConfig("last_failure.json", recordSettings(record)); err != nil {
+				logrus.Fatalf("Failed to write debug bundle: %s", err)
+			}
+			for _, kept := range record.Kept {
+				if !kept.Container {
+					continue
+				}
+				err := w.AddLogEntry(fmt.Sprintf("containers/%s.log", kept.ID), func(out io.Writer) error {
+					return container_run.WriteContainerLogs(kept.ID, out)
+				})
+				if err != nil {
+					logrus.Debugf("Can't add logs of %s %s: %s", kept.Description, kept.ID, err)
+				}
+			}
+		}
+
+		for _, include := range debugBundleIncludes {
+			absInclude := log.AbsPathOrExit(include, "include")
+			if err := w.AddFile(debug_bundle.EntryName("files", absInclude), absInclude); err != nil {
+				logrus.Warnf("Can't add %s: %s", absInclude, err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			logrus.Fatalf("Failed to write debug bundle: %s", err)
+		}
+		logrus.Infof("Debug bundle: %s", absOutputPath)
+		logrus.Info("Check it before attaching it to an issue, logs may contain paths and names from your project")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+	debugCmd.AddCommand(debugBundleCmd)
+
+	debugBundleCmd.Flags().StringVarP(&debugBundleOutputPath, "output", "o", "seqra-debug.zip", "Path to the debug bundle")
+	debugBundleCmd.Flags().StringVar(&debugBundleLogPath, "log", "", "Log file to include instead of the one of the last failed run")
+	debugBundleCmd.Flags().StringArrayVar(&debugBundleIncludes, "include", nil, "Additional file to include, e.g. the ruleset load errors")
+}
+
+// latestLogPath returns the newest log except the one of the current run
+func latestLogPath() string {
+	artifacts, err := home_cache.List()
+	if err != nil {
+		logrus.Warnf("Can't list logs: %s", err)
+		return ""
+	}
+	var latest *home_cache.Artifact
+	for i, artifact := range artifacts {
+		if artifact.Type != home_cache.ArtifactLog || artifact.Path == globals.LogPath {
+			continue
+		}
+		if latest == nil || artifact.ModTime.After(latest.ModTime) {
+			latest = &artifacts[i]
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Path
+}
+
+func versionLines() []string {
+	lines := []string{
+		"seqra: " + version.Version,
+		"analyzer: " + utils.GetAnalyzerImageLink(),
+		"autobuilder: " + utils.GetAutobuilderImageLink(),
+		"rules: " + globals.RulesBindVersion,
+		fmt.Sprintf("platform: %s/%s, %s", runtime.GOOS, runtime.GOARCH, runtime.Version()),
+	}
+	dockerVersion, err := container_run.DockerVersion()
+	if err != nil {
+		dockerVersion = "unavailable: " + err.Error()
+	}
+	return append(lines, "docker: "+dockerVersion)
+}
+
+func recordSettings(record *failure.Record) map[string]interface{} {
+	var kept []interface{}
+	for _, k := range record.Kept {
+		kept = append(kept, map[string]interface{}{"description": k.Description, "id": k.ID, "container": k.Container})
+	}
+	return map[string]interface{}{
+		"time":     record.Time,
+		"command":  debug_bundle.RedactArgs(record.Command),
+		"log_path": record.LogPath,
+		"kept":     kept,
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/seqrateam/seqra/internal/project_model"
+	"github.com/seqrateam/seqra/internal/utils"
+	"github.com/seqrateam/seqra/internal/utils/log"
+)
+
+var modelClasspathJoin bool
+
+// modelCmd represents the model command
+var modelCmd = &cobra.Command{
+	Use:   "model",
+	Short: "Inspect project models",
+	Long: `Inspect a project model created by "seqra compile": a directory with project.yaml and sources/.
+Use it to check compile results before running a long analysis.`,
+}
+
+var modelInfoCmd = &cobra.Command{
+	Use:   "info model",
+	Short: "Print a summary of the project model",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		absModelPath, model := readModelOrExit(args[0])
+
+		logrus.Infof("Project model: %s", absModelPath)
+		if size, err := utils.DirSize(absModelPath); err == nil {
+			logrus.Infof("Size: %s", units.HumanSize(float64(size)))
+		}
+		if model.SourceRoot != "" {
+			sourceRoot := project_model.Resolve(absModelPath, model.SourceRoot)
+			if size, err := utils.DirSize(sourceRoot); err == nil {
+				logrus.Infof("Sources: %s (%s)", model.SourceRoot, units.HumanSize(float64(size)))
+			} else {
+				logrus.Infof("Sources: %s (missing)", model.SourceRoot)
+			}
+		}
+		if model.JavaToolchain != "" {
+			logrus.Infof("Java toolchain: %s", model.JavaToolchain)
+		}
+		logrus.Infof("Modules: %d", len(model.Modules))
+		logrus.Infof("Dependencies: %d", len(model.Dependencies))
+
+		if unknown, err := project_model.UnknownKeys(absModelPath); err == nil && len(unknown) > 0 {
+			logrus.Infof("Other keys: %s", strings.Join(unknown, ", "))
+		}
+
+		errors, warnings := countProblems(project_model.Validate(absModelPath, model))
+		if errors+warnings > 0 {
+			logrus.Warnf("Problems: %d errors, %d warnings, run: seqra model validate %s", errors, warnings, args[0])
+		}
+	},
+}
+
+var modelModulesCmd = &cobra.Command{
+	Use:   "modules model",
+	Short: "List modules with their classes, sources and packages",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		_, model := readModelOrExit(args[0])
+
+		for i, module := range model.Modules {
+			logrus.Infof("=== %s ===", project_model.ModuleName(module, i))
+			if module.SourceRoot != "" {
+				logrus.Infof("Sources: %s", module.SourceRoot)
+			}
+			for _, classes := range module.Classes {
+				logrus.Infof("Classes: %s", classes)
+			}
+			logrus.Infof("Packages: %s", strings.Join(module.Packages, ", "))
+		}
+	},
+}
+
+var modelClasspathCmd = &cobra.Command{
+	Use:   "classpath model",
+	Short: "Print module classes and dependencies, one per line",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		absModelPath, model := readModelOrExit(args[0])
+
+		classpath := model.Classpath(absModelPath)
+		if modelClasspathJoin {
+			fmt.Println(strings.Join(classpath, string(os.PathListSeparator)))
+			return
+		}
+		for _, entry := range classpath {
+			fmt.Println(entry)
+		}
+	},
+}
+
+var modelValidateCmd = &cobra.Command{
+	Use:   "validate model",
+	Short: "Check that paths referenced by the project model exist",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		absModelPath, model := readModelOrExit(args[0])
+
+		problems := project_model.Validate(absModelPath, model)
+		for _, problem := range problems {
+			if problem.Error {
+				logrus.Errorf("Error: %s", problem.Message)
+			} else {
+				logrus.Warnf("Warning: %s", problem.Message)
+			}
+		}
+
+		errors, warnings := countProblems(problems)
+		if errors > 0 {
+			logrus.Fatalf("Project model is invalid: %d errors, %d warnings", errors, warnings)
+		}
+		logrus.Infof("Project model is valid: %d modules, %d dependencies, %d warnings", len(model.Modules), len(model.Dependencies), warnings)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(modelCmd)
+	modelCmd.AddCommand(modelInfoCmd, modelModulesCmd, modelClasspathCmd, modelValidateCmd)
+
+	modelClasspathCmd.Flags().BoolVar(&modelClasspathJoin, "join", false, "Print the classpath in one line joined with the path list separator")
+}
+
+func readModelOrExit(modelPath string) (string, *project_model.Model) {
+	absModelPath := log.AbsPathOrExit(modelPath, "model")
+	model, err := project_model.Read(absModelPath)
+	if err != nil {
+		logrus.Fatalf("Can't read project model %s: %s", absModelPath, err)
+	}
+	return absModelPath, model
+}
+
+func countProblems(problems []project_model.Problem) (errors, warnings int) {
+	for _, problem := range problems {
+		if problem.Error {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	return errors, warnings
+}
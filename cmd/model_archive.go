@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/failure"
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/model_archive"
+)
+
+var ArchivePath string
+
+// archiveProjectModel packs a compiled model into a relocatable archive with a manifest
+func archiveProjectModel(absModelPath, absArchivePath, absProjectRoot string) {
+	compileType := globals.Config.Compile.Type
+	if ArtifactsPath != "" {
+		compileType = "artifacts"
+	}
+	manifest := model_archive.Manifest{
+		AutobuilderVersion: globals.Config.Autobuilder.Version,
+		CompileType:        compileType,
+		SourceCommit:       model_archive.SourceCommit(absProjectRoot),
+		ProjectRoot:        absProjectRoot,
+	}
+
+	kept, err := model_archive.Create(absModelPath, absArchivePath, []string{dockerResultDir}, manifest)
+	if err != nil {
+		logrus.Fatalf("Failed to archive project model: %s", err)
+	}
+	for _, path := range kept {
+		logrus.Warnf("Project model archive refers to %s, which must exist where it's scanned", path)
+	}
+	logrus.Infof("Project model archive: %s", absArchivePath)
+}
+
+// extractProjectModel unpacks a model archive into a temporary directory, which the returned function removes
+func extractProjectModel(absArchivePath string) (string, func()) {
+	tempDirName, err := os.MkdirTemp("", "seqra-*")
+	if err != nil {
+		logrus.Fatalf("Failed to create temporary directory: %s", err)
+	}
+	absModelPath := tempDirName + "/project-model"
+	untrack := failure.Track("extracted project model", absModelPath, func() error {
+		return os.RemoveAll(tempDirName)
+	})
+	cleanup := func() {
+		untrack()
+		if err := os.RemoveAll(tempDirName); err != nil {
+			logrus.Warnf("Failed to remove temporary directory %s: %v", tempDirName, err)
+		}
+	}
+
+	manifest, err := model_archive.Extract(absArchivePath, absModelPath)
+	if err != nil {
+		logrus.Fatalf("Can't extract project model archive %s: %s", absArchivePath, err)
+	}
+	logrus.Infof("Project model archive created by seqra %s at %s", manifest.SeqraVersion, manifest.Created.Format("2006-01-02 15:04:05"))
+	logrus.Infof("Compiled with %s autobuilder %s", manifest.CompileType, manifest.AutobuilderVersion)
+	if manifest.SourceCommit != "" {
+		logrus.Infof("Source commit: %s", manifest.SourceCommit)
+	}
+	return absModelPath, cleanup
+}
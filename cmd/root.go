@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/ignore"
+	"github.com/seqrateam/seqra/internal/phase"
 	"github.com/seqrateam/seqra/internal/utils/log"
 	"github.com/seqrateam/seqra/internal/version"
 	"github.com/sirupsen/logrus"
@@ -30,6 +32,10 @@ var rootCmd = &cobra.Command{
 			return fmt.Errorf("failed to set up logging: %w", err)
 		}
 
+		if globals.Config.Deadline > 0 {
+			phase.SetDeadline(globals.Config.Deadline)
+		}
+
 		if cmd.Annotations != nil && cmd.Annotations["PrintConfig"] == "true" {
 			logrus.Infof("=== Config ===")
 			logrus.Infof("Log level: %s", globals.Config.Log.Verbosity)
@@ -37,6 +43,17 @@ var rootCmd = &cobra.Command{
 				logrus.Infof("Using config file: %v", viper.ConfigFileUsed())
 			}
 			logrus.Infof("Logging to file: %s", globals.LogPath)
+			if absProjectRoot := nativeCompileProject(args); absProjectRoot != "" {
+				// Printed here once, the native compile uses the same JDK
+				if _, err := findProjectJDK(absProjectRoot); err != nil {
+					logrus.Warnf("Java: %s", err)
+				}
+			} else if globals.Config.Compile.JavaHome != "" {
+				logrus.Infof("Java home: %s", globals.Config.Compile.JavaHome)
+			}
+			if globals.Config.Deadline > 0 {
+				logrus.Infof("Deadline: %s", globals.Config.Deadline)
+			}
 		}
 
 		return nil
@@ -76,6 +93,36 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&globals.Config.Quiet, "quiet", "q", false, "Suppress interactive console output. (default: false)")
 	_ = viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
 
+	rootCmd.PersistentFlags().BoolVar(&globals.Config.Offline, "offline", false, "Forbid any network access, use only local images and artifacts")
+	_ = viper.BindPFlag("offline", rootCmd.PersistentFlags().Lookup("offline"))
+
+	rootCmd.PersistentFlags().BoolVar(&globals.Config.KeepOnFailure, "keep-on-failure", false, "Keep the container and temporary files of a failed run for debugging")
+	_ = viper.BindPFlag("keep_on_failure", rootCmd.PersistentFlags().Lookup("keep-on-failure"))
+
+	rootCmd.PersistentFlags().StringVar(&globals.Config.Pull.Policy, "pull", globals.PullAlways, "Docker image pull policy (always, missing, never)")
+	_ = viper.BindPFlag("pull.policy", rootCmd.PersistentFlags().Lookup("pull"))
+
+	rootCmd.PersistentFlags().DurationVar(&globals.Config.Pull.Timeout, "pull-timeout", 0, "Timeout for a Docker image pull or an artifact download (default: no limit)")
+	_ = viper.BindPFlag("pull.timeout", rootCmd.PersistentFlags().Lookup("pull-timeout"))
+
+	rootCmd.PersistentFlags().DurationVar(&globals.Config.Deadline, "deadline", 0, "Time limit of the whole run, all phases are stopped when it passes (default: no limit)")
+	_ = viper.BindPFlag("deadline", rootCmd.PersistentFlags().Lookup("deadline"))
+
+	rootCmd.PersistentFlags().StringVar(&globals.Config.Mount.Mode, "mount-mode", globals.MountCopy, "How files are passed to containers (copy, bind)")
+	_ = viper.BindPFlag("mount.mode", rootCmd.PersistentFlags().Lookup("mount-mode"))
+
+	rootCmd.PersistentFlags().StringVar(&globals.Config.Resources.Memory, "memory", "", "Memory limit of analyzer and autobuilder containers, e.g. 8g")
+	_ = viper.BindPFlag("resources.memory", rootCmd.PersistentFlags().Lookup("memory"))
+
+	rootCmd.PersistentFlags().Float64Var(&globals.Config.Resources.CPUs, "cpus", 0, "Number of CPUs available to analyzer and autobuilder containers")
+	_ = viper.BindPFlag("resources.cpus", rootCmd.PersistentFlags().Lookup("cpus"))
+
+	rootCmd.PersistentFlags().Int64Var(&globals.Config.Resources.PidsLimit, "pids-limit", 0, "Process limit of analyzer and autobuilder containers")
+	_ = viper.BindPFlag("resources.pids_limit", rootCmd.PersistentFlags().Lookup("pids-limit"))
+
+	rootCmd.PersistentFlags().BoolVar(&globals.Config.Pull.RequireDigest, "require-image-digest", false, "Refuse to run images which aren't pinned by analyzer.digest and autobuilder.digest in the config")
+	_ = viper.BindPFlag("pull.require_digest", rootCmd.PersistentFlags().Lookup("require-image-digest"))
+
 	rootCmd.PersistentFlags().StringVar(&globals.Config.Analyzer.Version, "analyzer-version", globals.AnalyzerBindVersion, "Version of seqra analyzer")
 	_ = rootCmd.PersistentFlags().MarkHidden("analyzer-version")
 	_ = viper.BindPFlag("analyzer.version", rootCmd.PersistentFlags().Lookup("analyzer-version"))
@@ -107,3 +154,24 @@ func initConfig() {
 func bindCompileTypeFlag(cmd *cobra.Command) {
 	_ = viper.BindPFlag("compile.type", cmd.Flags().Lookup("compile-type"))
 }
+
+func bindCompileTimeoutFlag(cmd *cobra.Command) {
+	_ = viper.BindPFlag("compile.timeout", cmd.Flags().Lookup("compile-timeout"))
+}
+
+func bindJavaHomeFlag(cmd *cobra.Command) {
+	_ = viper.BindPFlag("compile.java_home", cmd.Flags().Lookup("java-home"))
+}
+
+func bindExcludeFlag(cmd *cobra.Command) {
+	_ = viper.BindPFlag("exclude", cmd.Flags().Lookup("exclude"))
+}
+
+// loadExcludes returns the .seqraignore of the project combined with --exclude patterns
+func loadExcludes(absProjectRoot string) *ignore.Matcher {
+	matcher, err := ignore.Load(absProjectRoot, globals.Config.Exclude)
+	if err != nil {
+		logrus.Fatalf("Can't load exclude patterns: %s", err)
+	}
+	return matcher
+}
@@ -13,13 +13,20 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
 
 	"github.com/seqrateam/seqra/internal/container_run"
+	"github.com/seqrateam/seqra/internal/failure"
 	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/ignore"
 	"github.com/seqrateam/seqra/internal/load_errors"
+	"github.com/seqrateam/seqra/internal/model_archive"
+	"github.com/seqrateam/seqra/internal/model_cache"
+	"github.com/seqrateam/seqra/internal/phase"
 	"github.com/seqrateam/seqra/internal/sarif"
 	"github.com/seqrateam/seqra/internal/utils"
 	"github.com/seqrateam/seqra/internal/utils/log"
+	"github.com/seqrateam/seqra/internal/workspace"
 )
 
 var UserProjectPath string
@@ -32,19 +39,40 @@ var SemgrepCompatibilitySarif bool
 var scanCmd = &cobra.Command{
 	Use:   "scan project",
 	Short: "Scan your Java project",
-	Args:  cobra.MinimumNArgs(1), // require at least one argument
+	Args: func(cmd *cobra.Command, args []string) error {
+		if WorkspacePath != "" || ArtifactsPath != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(1)(cmd, args) // require at least one argument
+	},
 	Long: `This command automatically detects Java build system, build project and analyze it
 
 Arguments:
-  project  - Path to a project or a project model (required)
+  project  - Path to a project, a project model or a project model archive (required unless --workspace or --jars is used)
 `,
 	Annotations: map[string]string{"PrintConfig": "true"},
 	PreRun: func(cmd *cobra.Command, args []string) {
 		bindCompileTypeFlag(cmd)
+		bindCompileTimeoutFlag(cmd)
+		bindJavaHomeFlag(cmd)
+		bindBuildHintFlags(cmd)
+		bindExcludeFlag(cmd)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		UserProjectPath = args[0]
-		scan()
+		if WorkspacePath != "" {
+			scanWorkspace()
+			return
+		}
+		if ArtifactsPath != "" {
+			UserProjectPath = ArtifactsPath
+		} else {
+			UserProjectPath = args[0]
+		}
+		// Exit after scan, so that its deferred cleanup runs
+		if incomplete := scan(); incomplete != nil {
+			incomplete.Print()
+			logrus.Fatal("Analysis is incomplete, the report contains partial results")
+		}
 	},
 }
 
@@ -57,16 +85,69 @@ func init() {
 	scanCmd.Flags().StringVar(&globals.Config.Scan.Ruleset, "ruleset", "", "Directory containing YAML rules")
 	_ = viper.BindPFlag("scan.ruleset", scanCmd.Flags().Lookup("ruleset"))
 
+	scanCmd.Flags().StringVar(&globals.Config.Scan.Type, "scan-type", "docker", "Environment for run analyzer (docker, native)")
+	_ = viper.BindPFlag("scan.type", scanCmd.Flags().Lookup("scan-type"))
+
 	scanCmd.Flags().StringVar(&globals.Config.Compile.Type, "compile-type", "docker", "Environment for run compile command (docker, native)")
+	scanCmd.Flags().DurationVar(&globals.Config.Compile.Timeout, "compile-timeout", 0, "Timeout for compile (default: no limit)")
+	scanCmd.Flags().StringVar(&globals.Config.Compile.JavaHome, "java-home", "", "JDK used by native compile (default: an installed JDK matching the project)")
+	scanCmd.Flags().StringSliceVar(&globals.Config.Exclude, "exclude", nil, "Project paths not to send to the container and not to report findings in, in gitignore syntax (added to .seqraignore)")
+	addBuildHintFlags(scanCmd)
+	scanCmd.Flags().StringVar(&ArtifactsPath, "jars", "", "Scan pre-built JARs, WARs or class directories in this directory instead of a project")
+	scanCmd.Flags().StringSliceVar(&ArtifactSourcePaths, "sources", nil, "Source directory or sources JAR of the --jars artifacts")
 	scanCmd.Flags().StringVar(&RuleSetLoadErrorsPath, "ruleset-load-errors", "", "Path to log ruleset load errors")
 	scanCmd.Flags().BoolVar(&SemgrepCompatibilitySarif, "semgrep-compatibility-sarif", true, "Use Semgrep compatible ruleId")
 	scanCmd.Flags().StringVarP(&SarifReportPath, "output", "o", "", "Path to the SARIF-report output file")
+	scanCmd.Flags().BoolVar(&globals.Config.Scan.Incremental, "incremental", false, "Reanalyze only files changed since the previous scan of the project and reuse its other findings")
+	_ = viper.BindPFlag("scan.incremental", scanCmd.Flags().Lookup("incremental"))
+
 	scanCmd.Flags().BoolVar(&OnlyScan, "only-scan", false, "Only scan the project, expecting a project model")
+	scanCmd.Flags().BoolVar(&globals.Config.ModelCache.Disabled, "no-cache", false, "Always compile the project instead of reusing a cached project model")
+	_ = viper.BindPFlag("model_cache.disabled", scanCmd.Flags().Lookup("no-cache"))
+
+	scanCmd.Flags().StringVar(&globals.Config.ModelCache.MaxSize, "model-cache-max-size", "10GB", "Maximum size of cached project models, least recently used models are evicted")
+	_ = viper.BindPFlag("model_cache.max_size", scanCmd.Flags().Lookup("model-cache-max-size"))
+
+	scanCmd.Flags().StringVar(&WorkspacePath, "workspace", "", "Path to a workspace manifest (or a directory containing "+workspace.ManifestFileName+") to scan several projects")
+	scanCmd.Flags().IntVar(&WorkspaceConcurrency, "concurrency", 0, "Number of workspace projects processed in parallel (default: manifest value or 1)")
+	scanCmd.Flags().StringVar(&WorkspaceOutputDir, "output-dir", "", "Directory for per-project SARIF reports and logs of a workspace scan (default: seqra-results next to the manifest)")
 }
 
 const defaultDataPath = "/data"
 
-func scan() {
+// ensureBundledRuleset downloads the bundled ruleset if it isn't present in seqra home yet
+func ensureBundledRuleset() string {
+	rulesPath, err := utils.GetRulesPath(globals.RulesBindVersion)
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to construct path to the ruleset: %s", err)
+	}
+
+	if _, err := os.Stat(rulesPath); err == nil {
+		if err := utils.VerifyCachedDir(rulesPath); err != nil {
+			logrus.Warnf("Cached ruleset is damaged and will be downloaded again: %s", err)
+			if err := utils.RemoveCachedArtifact(rulesPath); err != nil {
+				logrus.Fatalf("Unexpected error occurred while trying to remove damaged ruleset: %s", err)
+			}
+		}
+	}
+
+	if _, err := os.Stat(rulesPath); errors.Is(err, os.ErrNotExist) {
+		logrus.Info("Download seqra-rules")
+		err := utils.DownloadAndUnpackGithubReleaseArchive(globals.RepoOwner, globals.RulesRepoName, globals.RulesBindVersion, rulesPath, globals.Config.Github.Token)
+		if err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to download ruleset: %s", err)
+		}
+	}
+
+	return rulesPath
+}
+
+// scan scans the project and returns what the analysis didn't finish, nil when the report is complete
+func scan() *sarif.Incompleteness {
+	if globals.Config.Scan.Type != "docker" && globals.Config.Scan.Type != "native" {
+		logrus.Fatalf("scan-type must be one of \"docker\", \"native\"")
+	}
+
 	var absProjectModelPath string
 	var tempDirName string // Store the temp directory name for cleanup
 
@@ -74,12 +155,37 @@ func scan() {
 	userProjectPath = filepath.Clean(userProjectPath)
 	absUserProjectRoot := log.AbsPathOrExit(userProjectPath, "project path")
 
+	// A project model has no .seqraignore, only --exclude patterns apply to it
+	excludes := loadExcludes("")
+
 	logrus.Info()
 	tempProjectModel := false
+	cachedProjectModel := false
 	var tempProjectModelPath string
+	var modelCacheKey string
+	untrackTempDir := func() {}
 
 	// Resolve project type
-	if OnlyScan {
+	if ArtifactsPath != "" {
+		logrus.Infof("=== Artifacts Scan mode ===")
+		var err error
+		tempDirName, err = os.MkdirTemp("", "seqra-*")
+		if err != nil {
+			logrus.Fatalf("Failed to create temporary directory: %s", err)
+		}
+		absProjectModelPath = tempDirName + "/project-model"
+		tempDir := tempDirName
+		untrackTempDir = failure.Track("temporary project model", absProjectModelPath, func() error {
+			return os.RemoveAll(tempDir)
+		})
+		logrus.Infof("Artifacts: %s", absUserProjectRoot)
+		compileFromArtifacts(absUserProjectRoot, absProjectModelPath)
+	} else if model_archive.IsArchive(absUserProjectRoot) {
+		logrus.Infof("=== Scan only mode===")
+		var cleanupModel func()
+		absProjectModelPath, cleanupModel = extractProjectModel(absUserProjectRoot)
+		defer cleanupModel()
+	} else if OnlyScan {
 		logrus.Infof("=== Scan only mode===")
 		absProjectModelPath = absUserProjectRoot
 	} else {
@@ -90,69 +196,61 @@ func scan() {
 		} else if errors.Is(err, os.ErrNotExist) {
 			tempProjectModel = true
 			logrus.Infof("=== Compile and Scan mode ===")
-			tempDirName, err = os.MkdirTemp("", "seqra-*")
-			if err != nil {
-				logrus.Fatalf("Failed to create temporary directory: %s", err)
+			excludes = loadExcludes(absUserProjectRoot)
+			if !globals.Config.ModelCache.Disabled {
+				hashing := phase.Begin("Hashing of the project", "", 0)
+				modelCacheKey, err = model_cache.Key(hashing.Context(), absUserProjectRoot, globals.Config.Compile.Type, globals.Config.Autobuilder.Version, excludes.Patterns(""), buildHintFlags())
+				if hashing.Expired() {
+					logrus.Fatalf("%s, check the full logs: %s", hashing.Error(), globals.LogPath)
+				}
+				hashing.End()
+				if err != nil {
+					logrus.Warnf("Project model cache is disabled: %s", err)
+				} else if cachedModelPath, release, ok := model_cache.Lookup(modelCacheKey); ok {
+					cachedProjectModel = true
+					absProjectModelPath = cachedModelPath
+					defer release()
+				}
+			}
+			if !cachedProjectModel {
+				tempDirName, err = os.MkdirTemp("", "seqra-*")
+				if err != nil {
+					logrus.Fatalf("Failed to create temporary directory: %s", err)
+				}
+				tempProjectModelPath = tempDirName + "/project-model"
+				absProjectModelPath = tempProjectModelPath
+				tempDir := tempDirName
+				untrackTempDir = failure.Track("temporary project model", tempProjectModelPath, func() error {
+					return os.RemoveAll(tempDir)
+				})
 			}
-			tempProjectModelPath = tempDirName + "/project-model"
-			absProjectModelPath = tempProjectModelPath
 		} else {
 			logrus.Fatalf("Unexpected error occurred while checking the project: %s", err)
 		}
 	}
-	if tempProjectModel {
+	if cachedProjectModel {
+		logrus.Infof("Project: %s", absUserProjectRoot)
+		logrus.Infof("Cached project model: %s", absProjectModelPath)
+	} else if tempProjectModel {
 		logrus.Infof("Project: %s", absUserProjectRoot)
 		logrus.Infof("Temporary project model: %s", absProjectModelPath)
 	} else {
 		logrus.Infof("Project model: %s", absProjectModelPath)
 	}
 
-	var resultbase = defaultDataPath
 	var absRuleSetPath string
 	var userRuleSetPath = globals.Config.Scan.Ruleset
 
 	if userRuleSetPath != "" {
 		absRuleSetPath = log.AbsPathOrExit(userRuleSetPath, "ruleset")
-		if strings.HasPrefix(absRuleSetPath, defaultDataPath) {
-			resultbase = "/projectData"
-		}
 		logrus.Infof("User ruleset: %s", absRuleSetPath)
 	} else {
-		rulesPath, err := utils.GetRulesPath(globals.RulesBindVersion)
-		if err != nil {
-			logrus.Fatalf("Unexpected error occurred while trying to construct path to the ruleset: %s", err)
-		}
-
-		if _, err := os.Stat(rulesPath); errors.Is(err, os.ErrNotExist) {
-			logrus.Info("Download seqra-rules")
-			err := utils.DownloadAndUnpackGithubReleaseArchive(globals.RepoOwner, globals.RulesRepoName, globals.RulesBindVersion, rulesPath, globals.Config.Github.Token)
-			if err != nil {
-				logrus.Fatalf("Unexpected error occurred while trying to download ruleset: %s", err)
-			}
-		}
-
+		rulesPath := ensureBundledRuleset()
 		absRuleSetPath = rulesPath
 		logrus.Infof("Use bundled ruleset: %s", absRuleSetPath)
 	}
 
-	dockerProjectPath := resultbase + "/project"
-	dockerProjectYamlPath := dockerProjectPath + "/project.yaml"
-	dockerOutputDir := resultbase + "/reports"
-	dockerSarif := dockerOutputDir + "/report-ifds.sarif"
-	dockerRulesetErrors := dockerOutputDir + "/rule-errors.json"
-
-	hostConfig := &container.HostConfig{}
-
-	// Get the current user's UID and GID
-	containerUID := fmt.Sprintf("%d", os.Getuid())
-	containerGID := fmt.Sprintf("%d", os.Getgid())
-
-	envCont := []string{"CONTAINER_UID=" + containerUID, "CONTAINER_GID=" + containerGID}
-
-	analyzerFlags := []string{
-		"--project", dockerProjectYamlPath,
-		"--output-dir", dockerOutputDir,
-	}
+	var analyzerFlags []string
 
 	switch globals.Config.Log.Verbosity {
 	case "info":
@@ -163,28 +261,14 @@ func scan() {
 
 	analyzerFlags = append(analyzerFlags, fmt.Sprintf("--ifds-analysis-timeout=%d", globals.Config.Scan.Timeout/time.Second))
 
-	var copyToContainer = make(map[string]string)
-
-	copyToContainer[absProjectModelPath] = dockerProjectPath
-
-	var copyFromContainer = make(map[string]string)
-
 	var absSarifReportPath string
 	if SarifReportPath != "" {
 		absSarifReportPath = log.AbsPathOrExit(SarifReportPath, "output")
 	} else {
 		absSarifReportPath = filepath.Join(os.TempDir(), "seqra-scan.sarif.temp")
 	}
-
-	copyFromContainer[dockerSarif] = absSarifReportPath
 	utils.RemoveIfExistsOrExit(absSarifReportPath)
 
-	if absRuleSetPath != "" {
-		analyzerFlags = append(analyzerFlags, "--semgrep-rule-set")
-		analyzerFlags = append(analyzerFlags, absRuleSetPath)
-		copyToContainer[absRuleSetPath] = absRuleSetPath
-	}
-
 	var absRulesetLoadErrorsPath = ""
 	if RuleSetLoadErrorsPath != "" {
 		if absRuleSetPath == "" {
@@ -193,25 +277,74 @@ func scan() {
 
 		absRulesetLoadErrorsPath = log.AbsPathOrExit(RuleSetLoadErrorsPath, "ruleset-load-errors")
 		logrus.Infof("Load ruleset errors: %s", absRulesetLoadErrorsPath)
-
-		analyzerFlags = append(analyzerFlags, "--semgrep-rule-load-errors")
-		analyzerFlags = append(analyzerFlags, dockerRulesetErrors)
-		copyFromContainer[dockerRulesetErrors] = absRulesetLoadErrorsPath
 		utils.RemoveIfExistsOrExit(absRulesetLoadErrorsPath)
 	}
 
-	analyzerImageLink := utils.GetImageLink(globals.Config.Analyzer.Version, globals.AnalyzerDocker)
+	var inc *incrementalScan
+	if globals.Config.Scan.Incremental {
+		if tempProjectModel {
+			inc = prepareIncrementalScan(absUserProjectRoot, excludes, absRuleSetPath)
+			if inc.reanalyzesChanges() && !supportsChangedClasses(globals.Config.Scan.Type) {
+				inc.scanFully(fmt.Sprintf("analyzer %s can't reanalyze only changed classes", globals.Config.Analyzer.Version))
+			}
+		} else {
+			logrus.Warn("Incremental scan needs a project, the project model is scanned fully")
+		}
+	}
 
-	if tempProjectModel {
-		compile(absUserProjectRoot, tempProjectModelPath, globals.Config.Compile.Type)
+	if tempProjectModel && !cachedProjectModel && !inc.unchanged() {
+		previous, releasePrevious := inc.previousModel()
+		compile(absUserProjectRoot, tempProjectModelPath, globals.Config.Compile.Type, previous)
+		releasePrevious()
+		if modelCacheKey != "" {
+			var release func()
+			absProjectModelPath, release = storeProjectModel(modelCacheKey, tempProjectModelPath)
+			defer release()
+		}
 	}
 
-	container_run.RunGhcrContainer("Scan", analyzerImageLink, analyzerFlags, envCont, hostConfig, copyToContainer, copyFromContainer)
+	if inc.unchanged() {
+		inc.reusePreviousReport(absSarifReportPath)
+	} else {
+		absChangedClassesPath := inc.writeChangedClasses()
+		if absChangedClassesPath != "" {
+			defer func() {
+				_ = os.Remove(absChangedClassesPath)
+			}()
+		}
+
+		logrus.Infof("Scan mode: %s", globals.Config.Scan.Type)
+		runAnalyzer := func(absChangedClassesPath string) {
+			switch globals.Config.Scan.Type {
+			case "docker":
+				scanWithDocker(absProjectModelPath, absRuleSetPath, absSarifReportPath, absRulesetLoadErrorsPath, absChangedClassesPath, analyzerFlags)
+			case "native":
+				scanWithNative(absProjectModelPath, absRuleSetPath, absSarifReportPath, absRulesetLoadErrorsPath, absChangedClassesPath, analyzerFlags)
+			}
+		}
+		runAnalyzer(absChangedClassesPath)
+
+		if _, err := os.Stat(absSarifReportPath); err == nil && !inc.mergePreviousResults(absSarifReportPath) {
+			logrus.Info("Findings of the previous scan can't be matched to the changed files, the project is scanned fully")
+			utils.RemoveIfExistsOrExit(absSarifReportPath)
+			if absRulesetLoadErrorsPath != "" {
+				utils.RemoveIfExistsOrExit(absRulesetLoadErrorsPath)
+			}
+			runAnalyzer("")
+		}
+	}
+
+	if !excludes.Empty() {
+		removeExcludedResults(absSarifReportPath, excludes)
+	}
+	if _, err := os.Stat(absSarifReportPath); err == nil {
+		inc.save(absSarifReportPath, modelCacheKey)
+	}
 
 	// Process the generated SARIF report if it exists
 	report := PrintSarifSummary(absSarifReportPath, true)
 	if report == nil {
-		return
+		return nil
 	}
 
 	if SarifReportPath == "" {
@@ -232,7 +365,7 @@ func scan() {
 			// Write the modified SARIF back to the same file
 			if err := sarif.WriteFile(report, absSarifReportPath); err != nil {
 				logrus.Warnf("Failed to write modified SARIF report: %v", err)
-				return
+				return report.Incomplete()
 			}
 			logrus.Debug("Successfully modified SARIF report")
 		}
@@ -252,7 +385,7 @@ func scan() {
 				// Write the modified SARIF back to the same file
 				if err := load_errors.SaveErrorsListToFile(el, absRulesetLoadErrorsPath); err != nil {
 					logrus.Warnf("Failed to write modified Semgrep rules load report: %v", err)
-					return
+					return report.Incomplete()
 				}
 				logrus.Debug("Successfully modified Semgrep rules load report")
 			}
@@ -260,11 +393,155 @@ func scan() {
 	}
 
 	// Clean up temporary directory if it was created
-	if tempProjectModel && tempDirName != "" {
-		if err := os.RemoveAll(filepath.Dir(absProjectModelPath)); err != nil {
-			logrus.Warnf("Failed to remove temporary directory %s: %v", filepath.Dir(absProjectModelPath), err)
+	untrackTempDir()
+	if tempDirName != "" {
+		if err := os.RemoveAll(tempDirName); err != nil {
+			logrus.Warnf("Failed to remove temporary directory %s: %v", tempDirName, err)
 		} else {
-			logrus.Debugf("Removed temporary directory: %s", filepath.Dir(absProjectModelPath))
+			logrus.Debugf("Removed temporary directory: %s", tempDirName)
 		}
 	}
+
+	// Partial results are kept, but the scan must not pass as a complete one
+	return report.Incomplete()
+}
+
+func scanWithDocker(absProjectModelPath, absRuleSetPath, absSarifReportPath, absRulesetLoadErrorsPath, absChangedClassesPath string, analyzerFlags []string) {
+	var resultbase = defaultDataPath
+	if strings.HasPrefix(absRuleSetPath, defaultDataPath) {
+		resultbase = "/projectData"
+	}
+
+	dockerProjectPath := resultbase + "/project"
+	dockerProjectYamlPath := dockerProjectPath + "/project.yaml"
+	dockerOutputDir := resultbase + "/reports"
+	dockerSarif := dockerOutputDir + "/report-ifds.sarif"
+	dockerRulesetErrors := dockerOutputDir + "/rule-errors.json"
+	dockerChangedClasses := resultbase + "/changed-classes.txt"
+
+	hostConfig := &container.HostConfig{}
+	container_run.ApplySandbox(hostConfig, globals.Config.Scan.Sandbox)
+
+	// Get the current user's UID and GID
+	containerUID := fmt.Sprintf("%d", os.Getuid())
+	containerGID := fmt.Sprintf("%d", os.Getgid())
+
+	envCont := []string{"CONTAINER_UID=" + containerUID, "CONTAINER_GID=" + containerGID}
+
+	analyzerJavaOptions := utils.JavaOptions(globals.Config.Analyzer.Heap, globals.Config.Analyzer.JavaOptions)
+	if globals.Config.Analyzer.Heap == "" && globals.Config.Resources.Memory != "" {
+		// The JVM takes a quarter of the container memory by default, the analyzer is the only process there
+		analyzerJavaOptions = append(analyzerJavaOptions, "-XX:MaxRAMPercentage=75")
+	}
+	envCont = utils.AppendJavaToolOptions(envCont, analyzerJavaOptions)
+
+	dockerFlags := []string{
+		"--project", dockerProjectYamlPath,
+		"--output-dir", dockerOutputDir,
+	}
+	dockerFlags = append(dockerFlags, analyzerFlags...)
+
+	var copyToContainer = make(map[string]string)
+	var copyFromContainer = make(map[string]string)
+
+	copyFromContainer[dockerSarif] = absSarifReportPath
+
+	if absRuleSetPath != "" {
+		dockerFlags = append(dockerFlags, "--semgrep-rule-set", absRuleSetPath)
+		copyToContainer[absRuleSetPath] = absRuleSetPath
+	}
+
+	if absRulesetLoadErrorsPath != "" {
+		dockerFlags = append(dockerFlags, "--semgrep-rule-load-errors", dockerRulesetErrors)
+		copyFromContainer[dockerRulesetErrors] = absRulesetLoadErrorsPath
+	}
+
+	if absChangedClassesPath != "" {
+		dockerFlags = append(dockerFlags, "--changed-classes", dockerChangedClasses)
+		copyToContainer[absChangedClassesPath] = dockerChangedClasses
+	}
+
+	copyToContainer[absProjectModelPath] = dockerProjectPath
+
+	task := phase.Begin("Scan", "scan.timeout", globals.Config.Scan.Timeout+scanGracePeriod)
+	defer task.End()
+	outcome := container_run.RunGhcrContainerPartial(task, utils.GetAnalyzerImageLink(), dockerFlags, envCont, hostConfig, copyToContainer, copyFromContainer, nil)
+	if reason := analyzerFailure(task, outcome.Stopped, outcome.ExitCode); reason != "" {
+		markPartialReport(absSarifReportPath, reason, outcome.Stopped, outcome.ExitCode)
+	}
+}
+
+// analyzerFailure describes why the analyzer didn't finish, empty if it did
+func analyzerFailure(task *phase.Phase, stopped bool, exitCode int) string {
+	if stopped {
+		return task.Error()
+	}
+	if exitCode != 0 {
+		return fmt.Sprintf("Analyzer exited with code %d", exitCode)
+	}
+	return ""
+}
+
+// markPartialReport keeps the results an analyzer which didn't finish in time managed to report,
+// marking the report incomplete. The analyzer ran out of time when it was stopped or when its report
+// tells that the analysis wasn't completed, other failures and a missing report fail the scan.
+func markPartialReport(absSarifReportPath, reason string, stopped bool, exitCode int) {
+	report, err := readSarif(absSarifReportPath)
+	if errors.Is(err, os.ErrNotExist) {
+		logrus.Fatalf("%s, check the full logs: %s", reason, globals.LogPath)
+	}
+	if err != nil {
+		logrus.Fatalf("%s and left an unreadable report: %s", reason, err)
+	}
+	if !stopped && report.Incomplete() == nil {
+		logrus.Fatalf("%s, check the full logs: %s", reason, globals.LogPath)
+	}
+	report.MarkIncomplete(reason, exitCode)
+	if err := sarif.WriteFile(report, absSarifReportPath); err != nil {
+		logrus.Fatalf("Failed to write SARIF report: %s", err)
+	}
+	logrus.Warnf("%s, the report contains partial results", reason)
+}
+
+// removeExcludedResults drops findings in excluded files from the SARIF report
+func removeExcludedResults(absSarifReportPath string, excludes *ignore.Matcher) {
+	data, err := os.ReadFile(absSarifReportPath)
+	if err != nil {
+		logrus.Warnf("Failed to read SARIF report: %v", err)
+		return
+	}
+	report, err := sarif.Parse(data)
+	if err != nil {
+		logrus.Warnf("Failed to parse SARIF report: %v", err)
+		return
+	}
+	removed := report.RemoveResults(excludes.Matches)
+	if removed == 0 {
+		return
+	}
+	logrus.Debugf("Removed %d findings in excluded files", removed)
+	if err := sarif.WriteFile(report, absSarifReportPath); err != nil {
+		logrus.Warnf("Failed to write SARIF report: %v", err)
+	}
+}
+
+// storeProjectModel moves a freshly compiled model into the cache and evicts old models.
+// It returns the path the model should be used from and the release of the cached model.
+func storeProjectModel(modelCacheKey, absProjectModelPath string) (string, func()) {
+	cachedModelPath, release, err := model_cache.Store(modelCacheKey, absProjectModelPath)
+	if err != nil {
+		logrus.Warnf("Failed to cache project model: %s", err)
+		return absProjectModelPath, func() {}
+	}
+	logrus.Debugf("Project model cached: %s", cachedModelPath)
+
+	maxSize, err := units.FromHumanSize(globals.Config.ModelCache.MaxSize)
+	if err != nil {
+		logrus.Warnf("Invalid model cache max size %q: %s", globals.Config.ModelCache.MaxSize, err)
+		return cachedModelPath, release
+	}
+	if err := model_cache.Evict(maxSize, modelCacheKey); err != nil {
+		logrus.Warnf("Failed to evict cached project models: %s", err)
+	}
+	return cachedModelPath, release
 }
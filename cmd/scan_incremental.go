@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/ignore"
+	"github.com/seqrateam/seqra/internal/incremental"
+	"github.com/seqrateam/seqra/internal/model_cache"
+	"github.com/seqrateam/seqra/internal/sarif"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+// incrementalScan is the state of an incremental scan of a project
+type incrementalScan struct {
+	dir         string
+	analysisKey string
+	snapshot    *incremental.Snapshot
+	// previous is nil when the scan can't reuse the previous one
+	previous *incremental.State
+	changes  incremental.Changes
+}
+
+// prepareIncrementalScan compares the project with its previous scan, it returns nil when the state can't be kept
+func prepareIncrementalScan(absProjectRoot string, excludes *ignore.Matcher, absRuleSetPath string) *incrementalScan {
+	dir, err := incremental.Dir(absProjectRoot, globals.Config.Compile.Type)
+	if err != nil {
+		logrus.Warnf("Incremental scan is disabled: %s", err)
+		return nil
+	}
+	analysisKey, err := incremental.AnalysisKey(globals.Config.Analyzer.Version, absRuleSetPath)
+	if err != nil {
+		logrus.Warnf("Incremental scan is disabled: %s", err)
+		return nil
+	}
+	snapshot, err := incremental.TakeSnapshot(absProjectRoot, excludes)
+	if err != nil {
+		logrus.Warnf("Incremental scan is disabled: %s", err)
+		return nil
+	}
+	inc := &incrementalScan{dir: dir, analysisKey: analysisKey, snapshot: snapshot}
+
+	previous, err := incremental.Load(dir)
+	switch {
+	case err != nil:
+		logrus.Warnf("Failed to read the previous scan, the project is scanned fully: %s", err)
+		return inc
+	case previous == nil:
+		logrus.Info("No previous scan, the project is scanned fully")
+		return inc
+	case previous.AnalysisKey != analysisKey:
+		logrus.Info("Analyzer or rules changed since the previous scan, the project is scanned fully")
+		return inc
+	}
+
+	inc.changes = incremental.Compare(previous.Snapshot, snapshot)
+	if inc.changes.Full {
+		logrus.Infof("The project is scanned fully: %s", inc.changes.Reason)
+		return inc
+	}
+	inc.previous = previous
+	if inc.changes.Empty() {
+		logrus.Info("Nothing changed since the previous scan")
+		return inc
+	}
+	logrus.Infof("Changed since the previous scan: %d files in modules %s", len(inc.changes.Files), strings.Join(inc.changes.Modules, ", "))
+	return inc
+}
+
+// reanalyzesChanges tells whether only the changes since the previous scan are going to be analyzed
+func (inc *incrementalScan) reanalyzesChanges() bool {
+	return inc != nil && inc.previous != nil && !inc.changes.Empty()
+}
+
+// scanFully drops the previous scan, the project is compiled and analyzed fully and its state is saved for the next scan
+func (inc *incrementalScan) scanFully(reason string) {
+	logrus.Infof("The project is scanned fully: %s", reason)
+	inc.previous = nil
+}
+
+// unchanged tells whether the previous report can be used as is
+func (inc *incrementalScan) unchanged() bool {
+	return inc != nil && inc.previous != nil && inc.changes.Empty()
+}
+
+// previousModel returns the cached model of the previous scan to rebuild only changed modules from
+// and the release of the cached model
+func (inc *incrementalScan) previousModel() (*previousModel, func()) {
+	if inc == nil || inc.previous == nil || inc.previous.ModelKey == "" {
+		return nil, func() {}
+	}
+	absPath, release, ok := model_cache.Lookup(inc.previous.ModelKey)
+	if !ok {
+		logrus.Debug("Model of the previous scan isn't cached anymore, all modules are rebuilt")
+		return nil, func() {}
+	}
+	return &previousModel{absPath: absPath, modules: inc.changes.Modules}, release
+}
+
+// writeChangedClasses writes classes of changed sources to a temporary file for the analyzer, it returns the file
+func (inc *incrementalScan) writeChangedClasses() string {
+	if inc == nil || inc.previous == nil {
+		return ""
+	}
+	classes := incremental.ChangedClasses(inc.previous.Snapshot, inc.snapshot, inc.changes)
+	logrus.Infof("Reanalyze %d changed classes", len(classes))
+	file, err := os.CreateTemp("", "seqra-changed-classes-*.txt")
+	if err != nil {
+		logrus.Fatalf("Failed to write changed classes: %s", err)
+	}
+	_, err = file.WriteString(strings.Join(classes, "\n") + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		logrus.Fatalf("Failed to write changed classes: %s", err)
+	}
+	return file.Name()
+}
+
+// reusePreviousReport copies the report of the previous scan to absSarifReportPath
+func (inc *incrementalScan) reusePreviousReport(absSarifReportPath string) {
+	if err := utils.CopyFile(incremental.ReportPath(inc.dir), absSarifReportPath); err != nil {
+		logrus.Fatalf("Failed to copy the previous report: %s", err)
+	}
+	logrus.Info("Reuse the report of the previous scan")
+}
+
+// mergePreviousResults adds findings of the previous scan which don't touch changed files to the new report.
+// It returns false when findings of the previous scan can't be matched to files, the project must be scanned fully then.
+func (inc *incrementalScan) mergePreviousResults(absSarifReportPath string) bool {
+	if inc == nil || inc.previous == nil || inc.changes.Empty() {
+		return true
+	}
+	previous, err := readSarif(incremental.ReportPath(inc.dir))
+	if err != nil {
+		logrus.Warnf("Failed to read the previous report: %s", err)
+		return false
+	}
+	report, err := readSarif(absSarifReportPath)
+	if err != nil {
+		logrus.Warnf("Failed to read SARIF report: %s", err)
+		return true
+	}
+
+	changedFiles := inc.changes.Files
+	merged, ok := report.MergeResults(previous, func(uri string) bool {
+		for _, file := range changedFiles {
+			if file == uri || strings.HasSuffix(file, "/"+uri) {
+				return true
+			}
+		}
+		return false
+	})
+	if !ok {
+		return false
+	}
+	logrus.Debugf("Merged %d findings of the previous scan", merged)
+	if err := sarif.WriteFile(report, absSarifReportPath); err != nil {
+		logrus.Warnf("Failed to write SARIF report: %s", err)
+	}
+	return true
+}
+
+// save keeps the snapshot and the report for the next scan
+func (inc *incrementalScan) save(absSarifReportPath, modelCacheKey string) {
+	if inc == nil {
+		return
+	}
+	if report, err := readSarif(absSarifReportPath); err == nil && report.Incomplete() != nil {
+		// Findings missing from an incomplete report must not be reused as final ones
+		logrus.Debug("The report is incomplete, the state of the incremental scan isn't saved")
+		return
+	}
+	state := &incremental.State{ModelKey: modelCacheKey, AnalysisKey: inc.analysisKey, Snapshot: inc.snapshot}
+	if err := incremental.Save(inc.dir, state, absSarifReportPath); err != nil {
+		logrus.Warnf("Failed to save the state of the incremental scan: %s", err)
+	}
+}
+
+func readSarif(path string) (*sarif.Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return sarif.Parse(data)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/failure"
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/jdk"
+	"github.com/seqrateam/seqra/internal/phase"
+	"github.com/seqrateam/seqra/internal/utils"
+	"github.com/seqrateam/seqra/internal/utils/log"
+)
+
+// scanGracePeriod is added to the analysis timeout for loading the project model and writing reports
+const scanGracePeriod = 5 * time.Minute
+
+// ensureAnalyzerJar downloads the analyzer jar if it isn't present in seqra home yet
+func ensureAnalyzerJar() string {
+	analyzerJarPath, err := utils.GetAnalyzerJarPath(globals.Config.Analyzer.Version)
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to construct path to the analyzer: %s", err)
+	}
+
+	if _, err := os.Stat(analyzerJarPath); err == nil {
+		pinnedDigest, _ := globals.PinnedArtifactDigest(globals.AnalyzerRepoName, globals.Config.Analyzer.Version, globals.AnalyzerAsset())
+		if err := utils.VerifyCachedFile(analyzerJarPath, pinnedDigest); err != nil {
+			logrus.Warnf("Cached analyzer is damaged and will be downloaded again: %s", err)
+			if err := utils.RemoveCachedArtifact(analyzerJarPath); err != nil {
+				logrus.Fatalf("Unexpected error occurred while trying to remove damaged analyzer: %s", err)
+			}
+		}
+	}
+
+	if _, err := os.Stat(analyzerJarPath); errors.Is(err, os.ErrNotExist) {
+		logrus.Info("Download seqra analyzer")
+		err := utils.DownloadGithubReleaseAsset(globals.RepoOwner, globals.AnalyzerRepoName, globals.Config.Analyzer.Version, globals.AnalyzerAsset(), analyzerJarPath, globals.Config.Github.Token)
+		if errors.Is(err, utils.ErrAssetNotFound) {
+			logrus.Fatalf("Analyzer %s isn't published as %s: %s. Set analyzer.asset in the config to the name of its jar or use --scan-type docker", globals.Config.Analyzer.Version, globals.AnalyzerAsset(), err)
+		}
+		if err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to download analyzer: %s", err)
+		}
+	}
+
+	return analyzerJarPath
+}
+
+// findAnalyzerJava returns an installed JDK able to run the analyzer jar
+func findAnalyzerJava() jdk.JDK {
+	jdks := jdk.Discover()
+	for _, found := range jdks {
+		logrus.Debugf("Found JDK: %s", found)
+	}
+	analyzerJDK, ok := jdk.AtLeast(jdks, globals.AnalyzerJavaVersion, false)
+	if !ok {
+		logrus.Fatalf("Native scan needs Java %d or newer, install it or point JAVA_HOME to it", globals.AnalyzerJavaVersion)
+	}
+	logrus.Infof("Analyzer Java: %s", analyzerJDK)
+	return analyzerJDK
+}
+
+func scanWithNative(absProjectModelPath, absRuleSetPath, absSarifReportPath, absRulesetLoadErrorsPath, absChangedClassesPath string, analyzerFlags []string) {
+	analyzerJarPath := ensureAnalyzerJar()
+	javaPath := findAnalyzerJava().Java()
+
+	outputDir, err := os.MkdirTemp("", "seqra-reports-*")
+	if err != nil {
+		logrus.Fatalf("Failed to create temporary directory: %s", err)
+	}
+	untrackOutputDir := failure.Track("analyzer output", outputDir, func() error {
+		return os.RemoveAll(outputDir)
+	})
+	defer func() {
+		untrackOutputDir()
+		_ = os.RemoveAll(outputDir)
+	}()
+
+	analyzerCommand := utils.JavaOptions(globals.Config.Analyzer.Heap, globals.Config.Analyzer.JavaOptions)
+	analyzerCommand = append(analyzerCommand,
+		"-jar",
+		analyzerJarPath,
+		"--project", filepath.Join(absProjectModelPath, "project.yaml"),
+		"--output-dir", outputDir,
+	)
+	analyzerCommand = append(analyzerCommand, analyzerFlags...)
+	if absRuleSetPath != "" {
+		analyzerCommand = append(analyzerCommand, "--semgrep-rule-set", absRuleSetPath)
+	}
+	if absRulesetLoadErrorsPath != "" {
+		analyzerCommand = append(analyzerCommand, "--semgrep-rule-load-errors", absRulesetLoadErrorsPath)
+	}
+	if absChangedClassesPath != "" {
+		analyzerCommand = append(analyzerCommand, "--changed-classes", absChangedClassesPath)
+	}
+
+	task := phase.Begin("Scan", "scan.timeout", globals.Config.Scan.Timeout+scanGracePeriod)
+	defer task.End()
+
+	cmd := exec.CommandContext(task.Context(), javaPath, analyzerCommand...)
+	cmd.WaitDelay = nativeWaitDelay
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	logrus.Debugf("Running analyzer: %s %v", javaPath, analyzerCommand)
+	if err := cmd.Start(); err != nil {
+		logrus.Fatalf("Failed to start analyzer: %s", err)
+	}
+
+	progress := log.NewProgressLine("Scan", globals.Config.Quiet)
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		log.StreamLines(reader, logrus.WithField("process", "analyzer"), progress)
+	}()
+
+	err = cmd.Wait()
+	_ = writer.Close()
+	<-outputDone
+	progress.Stop()
+
+	stopped := task.Expired()
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if err != nil && !stopped {
+		if !errors.As(err, &exitErr) {
+			logrus.Fatalf("Analyzer failed: %s, check the full logs: %s", err, globals.LogPath)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+	reason := analyzerFailure(task, stopped, exitCode)
+
+	if err := moveReport(filepath.Join(outputDir, "report-ifds.sarif"), absSarifReportPath); err != nil {
+		if reason != "" {
+			logrus.Fatalf("%s, check the full logs: %s", reason, globals.LogPath)
+		}
+		logrus.Fatalf("Failed to save SARIF report: %s", err)
+	}
+	if reason != "" {
+		markPartialReport(absSarifReportPath, reason, stopped, exitCode)
+	}
+}
+
+// moveReport moves a file produced in a temporary directory, copying it when it's on another file system
+func moveReport(source, destination string) error {
+	if err := os.Rename(source, destination); err == nil {
+		return nil
+	}
+	return utils.CopyFile(source, destination)
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/container_run"
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/phase"
+	"github.com/seqrateam/seqra/internal/sarif"
+	"github.com/seqrateam/seqra/internal/utils"
+	"github.com/seqrateam/seqra/internal/utils/log"
+	"github.com/seqrateam/seqra/internal/workspace"
+)
+
+var WorkspacePath string
+var WorkspaceConcurrency int
+var WorkspaceOutputDir string
+
+const defaultWorkspaceOutputDir = "seqra-results"
+
+type workspaceResult struct {
+	Project   workspace.Project
+	SarifPath string
+	LogPath   string
+	Duration  time.Duration
+	Summary   *sarif.Summary
+	Err       error
+}
+
+func scanWorkspace() {
+	absManifestPath, err := workspace.ResolveManifestPath(WorkspacePath)
+	if err != nil {
+		logrus.Fatalf("Can't find workspace manifest: %s", err)
+	}
+
+	manifest, err := workspace.Load(absManifestPath)
+	if err != nil {
+		logrus.Fatalf("Invalid workspace manifest %s: %s", absManifestPath, err)
+	}
+
+	concurrency := WorkspaceConcurrency
+	if concurrency <= 0 {
+		concurrency = manifest.Concurrency
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	outputDir := WorkspaceOutputDir
+	if outputDir == "" {
+		outputDir = filepath.Join(manifest.Dir, defaultWorkspaceOutputDir)
+	}
+	absOutputDir := log.AbsPathOrExit(outputDir, "output-dir")
+	if err := os.MkdirAll(absOutputDir, 0755); err != nil {
+		logrus.Fatalf("Failed to create output directory %s: %s", absOutputDir, err)
+	}
+
+	logrus.Info()
+	logrus.Infof("=== Workspace scan mode ===")
+	logrus.Infof("Workspace manifest: %s", absManifestPath)
+	logrus.Infof("Projects: %d", len(manifest.Projects))
+	logrus.Infof("Concurrency: %d", concurrency)
+	logrus.Infof("Results directory: %s", absOutputDir)
+
+	prepareWorkspaceArtifacts(manifest)
+
+	executable, err := os.Executable()
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to locate seqra executable: %s", err)
+	}
+
+	results := make([]workspaceResult, len(manifest.Projects))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, project := range manifest.Projects {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() {
+				<-semaphore
+			}()
+			results[i] = scanWorkspaceProject(executable, project, absOutputDir)
+		}()
+	}
+	wg.Wait()
+
+	printWorkspaceSummary(results)
+}
+
+// prepareWorkspaceArtifacts pulls images and downloads shared artifacts once,
+// so that projects processed in parallel reuse them instead of racing on them
+func prepareWorkspaceArtifacts(manifest *workspace.Manifest) {
+	needBundledRuleset := false
+	needAutobuilderImage := false
+	needAutobuilderJar := false
+	for _, project := range manifest.Projects {
+		if workspaceRuleset(project) == "" {
+			needBundledRuleset = true
+		}
+		switch workspaceCompileType(project) {
+		case "docker":
+			needAutobuilderImage = true
+		case "native":
+			needAutobuilderJar = true
+		}
+	}
+
+	if needBundledRuleset {
+		ensureBundledRuleset()
+	}
+	if needAutobuilderJar {
+		ensureAutobuilderJar()
+	}
+	if needAutobuilderImage {
+		container_run.PullGhcrImage(utils.GetAutobuilderImageLink())
+	}
+	switch globals.Config.Scan.Type {
+	case "docker":
+		container_run.PullGhcrImage(utils.GetAnalyzerImageLink())
+	case "native":
+		ensureAnalyzerJar()
+	}
+}
+
+// workspaceRuleset returns the ruleset of the project, falling back to the one given to the workspace scan
+func workspaceRuleset(project workspace.Project) string {
+	if project.Ruleset != "" {
+		return project.Ruleset
+	}
+	if globals.Config.Scan.Ruleset != "" {
+		return log.AbsPathOrExit(globals.Config.Scan.Ruleset, "ruleset")
+	}
+	return ""
+}
+
+func workspaceCompileType(project workspace.Project) string {
+	if project.CompileType != "" {
+		return project.CompileType
+	}
+	return globals.Config.Compile.Type
+}
+
+func scanWorkspaceProject(executable string, project workspace.Project, absOutputDir string) workspaceResult {
+	result := workspaceResult{
+		Project:   project,
+		SarifPath: filepath.Join(absOutputDir, project.Name+".sarif"),
+		LogPath:   filepath.Join(absOutputDir, project.Name+".log"),
+	}
+
+	// The project enforces the deadline itself, the ones not started before it are skipped
+	task := phase.Begin("Project "+project.Name, "", 0)
+	defer task.End()
+	if task.Expired() {
+		result.Err = errors.New(task.Error())
+		logrus.Errorf("Skip project %s: %s", project.Name, result.Err)
+		return result
+	}
+
+	timeout := project.Timeout
+	if timeout == 0 {
+		timeout = globals.Config.Scan.Timeout
+	}
+
+	// Images were already pulled by the workspace, children only reuse them
+	pullPolicy := globals.PullMissing
+	if globals.Config.Pull.Policy == globals.PullNever {
+		pullPolicy = globals.PullNever
+	}
+
+	args := []string{
+		"scan", project.Path,
+		"--output", result.SarifPath,
+		"--compile-type", workspaceCompileType(project),
+		"--scan-type", globals.Config.Scan.Type,
+		"--timeout", timeout.String(),
+		"--pull", pullPolicy,
+		"--mount-mode", globals.Config.Mount.Mode,
+		"--verbosity", globals.Config.Log.Verbosity,
+		"--analyzer-version", globals.Config.Analyzer.Version,
+		"--autobuilder-version", globals.Config.Autobuilder.Version,
+		fmt.Sprintf("--semgrep-compatibility-sarif=%t", SemgrepCompatibilitySarif),
+		"--quiet",
+	}
+	if ruleset := workspaceRuleset(project); ruleset != "" {
+		args = append(args, "--ruleset", ruleset)
+	}
+	if globals.ConfigFile != "" {
+		args = append(args, "--config", globals.ConfigFile)
+	}
+	if resources := globals.Config.Resources; resources.Memory != "" || resources.CPUs != 0 || resources.PidsLimit != 0 {
+		args = append(args,
+			"--memory", resources.Memory,
+			"--cpus", fmt.Sprint(resources.CPUs),
+			"--pids-limit", fmt.Sprint(resources.PidsLimit),
+		)
+	}
+	if globals.Config.Compile.JavaHome != "" {
+		args = append(args, "--java-home", globals.Config.Compile.JavaHome)
+	}
+	for _, pattern := range globals.Config.Exclude {
+		args = append(args, "--exclude", pattern)
+	}
+	if globals.Config.Scan.Incremental {
+		args = append(args, "--incremental")
+	}
+	if globals.Config.Compile.Timeout > 0 {
+		args = append(args, "--compile-timeout", globals.Config.Compile.Timeout.String())
+	}
+	if globals.Config.Pull.Timeout > 0 {
+		args = append(args, "--pull-timeout", globals.Config.Pull.Timeout.String())
+	}
+	if remaining := phase.Remaining(); remaining > 0 {
+		// Projects share the deadline of the workspace scan
+		args = append(args, "--deadline", remaining.Round(time.Second).String())
+	}
+	if globals.Config.Pull.RequireDigest {
+		args = append(args, "--require-image-digest")
+	}
+	if globals.Config.KeepOnFailure {
+		args = append(args, "--keep-on-failure")
+	}
+	if globals.Config.Offline {
+		args = append(args, "--offline")
+	}
+
+	logFile, err := os.Create(result.LogPath)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to create log file: %w", err)
+		return result
+	}
+	defer func() {
+		_ = logFile.Close()
+	}()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Env = os.Environ()
+	if globals.Config.Github.Token != "" {
+		cmd.Env = append(cmd.Env, "SEQRA_GITHUB_TOKEN="+globals.Config.Github.Token)
+	}
+
+	logrus.Infof("Start project: %s", project.Name)
+	logrus.Debugf("Project command: %s %v", executable, args)
+
+	start := time.Now()
+	runErr := cmd.Run()
+	result.Duration = time.Since(start)
+
+	report, err := readSarif(result.SarifPath)
+	if runErr != nil && (err != nil || report.Incomplete() == nil) {
+		result.Err = runErr
+		logrus.Errorf("Project %s failed after %s: %s", project.Name, result.Duration.Round(time.Second), runErr)
+		return result
+	}
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read SARIF report: %w", err)
+		return result
+	}
+	// A project with partial results fails too, its summary still lists them
+	summary := sarif.GenerateSummary(report)
+	result.Summary = &summary
+
+	logrus.Infof("Finish project: %s (%s)", project.Name, result.Duration.Round(time.Second))
+	return result
+}
+
+func printWorkspaceSummary(results []workspaceResult) {
+	aggregated := sarif.Summary{FindingsByLevel: make(map[string]int)}
+	failed := 0
+
+	logrus.Info()
+	logrus.Info("=== Workspace Summary ===")
+	for _, result := range results {
+		duration := result.Duration.Round(time.Second)
+		if result.Err != nil {
+			failed++
+			logrus.Errorf("  %s: FAILED (%s), log: %s", result.Project.Name, result.Err, result.LogPath)
+			continue
+		}
+		aggregated.Add(*result.Summary)
+		if result.Summary.Incomplete {
+			failed++
+			logrus.Warnf("  %s: %d findings in %s, analysis incomplete, report: %s", result.Project.Name, result.Summary.TotalFindings, duration, result.SarifPath)
+			continue
+		}
+		logrus.Infof("  %s: %d findings in %s, report: %s", result.Project.Name, result.Summary.TotalFindings, duration, result.SarifPath)
+	}
+
+	logrus.Info()
+	logrus.Infof("Projects scanned: %d, failed: %d", len(results)-failed, failed)
+	logrus.Infof("Total findings: %d", aggregated.TotalFindings)
+	logrus.Info("Findings by severity:")
+	sarif.LogFindings(aggregated, "error")
+	sarif.LogFindings(aggregated, "warning")
+	sarif.LogFindings(aggregated, "note")
+
+	if failed > 0 {
+		logrus.Fatalf("%d of %d workspace projects failed", failed, len(results))
+	}
+}
@@ -22,7 +22,13 @@ Arguments:
 
 	Run: func(cmd *cobra.Command, args []string) {
 		absSarifPath := log.AbsPathOrExit(args[0], "sarif path")
-		PrintSarifSummary(absSarifPath, false)
+		report := PrintSarifSummary(absSarifPath, false)
+		if report == nil {
+			return
+		}
+		if incomplete := report.Incomplete(); incomplete != nil {
+			incomplete.Print()
+		}
 	},
 }
 
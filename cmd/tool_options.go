@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/container_run"
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/phase"
+	"github.com/seqrateam/seqra/internal/tool_options"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+// autobuilderHelp returns the options accepted by the autobuilder a compile of compileType runs
+func autobuilderHelp(compileType, absProjectRoot string) (*tool_options.Help, error) {
+	return tool_options.Load("autobuilder", globals.Config.Autobuilder.Version, "--project-root-dir", func() ([]byte, error) {
+		if compileType == "native" {
+			return jarHelp(selectProjectJDK(absProjectRoot).Java(), ensureAutobuilderJar())
+		}
+		return imageHelp(utils.GetAutobuilderImageLink())
+	})
+}
+
+// analyzerHelp returns the options accepted by the analyzer a scan of scanType runs
+func analyzerHelp(scanType string) (*tool_options.Help, error) {
+	return tool_options.Load("analyzer", globals.Config.Analyzer.Version, "--output-dir", func() ([]byte, error) {
+		if scanType == "native" {
+			return jarHelp(findAnalyzerJava().Java(), ensureAnalyzerJar())
+		}
+		return imageHelp(utils.GetAnalyzerImageLink())
+	})
+}
+
+func jarHelp(javaPath, jarPath string) ([]byte, error) {
+	task := phase.Begin("Reading of "+jarPath+" options", "", 0)
+	defer task.End()
+	output, err := exec.CommandContext(task.Context(), javaPath, "-jar", jarPath, "--help").CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	return output, nil
+}
+
+func imageHelp(imageLink string) ([]byte, error) {
+	task := phase.Begin("Reading of "+imageLink+" options", "", 0)
+	defer task.End()
+	env := []string{fmt.Sprintf("CONTAINER_UID=%d", os.Getuid()), fmt.Sprintf("CONTAINER_GID=%d", os.Getgid())}
+	return container_run.ImageOutput(task.Context(), imageLink, []string{"--help"}, env)
+}
+
+// checkBuildHints fails when the autobuilder doesn't accept the options of the build hints
+func checkBuildHints(compileType, absProjectRoot string, buildFlags []string) {
+	help, err := autobuilderHelp(compileType, absProjectRoot)
+	if err != nil {
+		logrus.Warnf("Can't check that autobuilder %s supports the build hints: %s", globals.Config.Autobuilder.Version, err)
+		return
+	}
+	if missing := help.Missing(buildFlags); len(missing) > 0 {
+		logrus.Fatalf("Autobuilder %s doesn't support %s, remove these build hints or set autobuilder.version to a release supporting them", globals.Config.Autobuilder.Version, strings.Join(missing, ", "))
+	}
+}
+
+// supportsPreviousModel tells whether the autobuilder can rebuild only changed modules of a previous model
+func supportsPreviousModel(compileType, absProjectRoot string) bool {
+	help, err := autobuilderHelp(compileType, absProjectRoot)
+	if err != nil {
+		logrus.Debugf("Can't read the options of autobuilder %s: %s", globals.Config.Autobuilder.Version, err)
+		return false
+	}
+	return len(help.Missing([]string{"--previous-model", "--subproject"})) == 0
+}
+
+// supportsChangedClasses tells whether the analyzer can reanalyze only changed classes
+func supportsChangedClasses(scanType string) bool {
+	help, err := analyzerHelp(scanType)
+	if err != nil {
+		logrus.Debugf("Can't read the options of analyzer %s: %s", globals.Config.Analyzer.Version, err)
+		return false
+	}
+	return help.Supports("--changed-classes")
+}
@@ -0,0 +1,221 @@
+package build_env
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+const (
+	ContainerCertsPath      = "/data/certs"
+	ContainerM2Path         = "/data/m2"
+	ContainerGradleHomePath = "/data/gradle-home"
+
+	trustStoreName = "truststore.jks"
+)
+
+var proxyEnvNames = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"}
+
+// Setup is the environment of a build: variables to set and, for containers,
+// host directories to copy into the container keyed by their host path and mounts
+type Setup struct {
+	Env    []string
+	Files  map[string]string
+	Mounts []mount.Mount
+}
+
+// ForContainer forwards proxy settings, network.ca_bundle, compile.maven_settings and
+// compile.gradle_init_scripts into the autobuilder container. Files are staged in workDir,
+// readImageFile reads files of the autobuilder image, like the default trust store of its JDK.
+func ForContainer(workDir string, readImageFile func(path string) ([]byte, error)) (*Setup, error) {
+	setup := &Setup{Files: make(map[string]string)}
+	setup.Env = append(setup.Env, ProxyEnv()...)
+	javaOptions := JavaProxyOptions()
+
+	if caBundle := globals.Config.Network.CABundle; caBundle != "" {
+		certsDir := filepath.Join(workDir, "certs")
+		if err := os.MkdirAll(certsDir, 0755); err != nil {
+			return nil, err
+		}
+		var defaultPaths []string
+		for _, relative := range jdkTrustStorePaths {
+			defaultPaths = append(defaultPaths, "$JAVA_HOME/"+relative)
+		}
+		defaultCerts := readDefaultCerts(defaultPaths, readImageFile)
+		if err := WriteTrustStore(caBundle, defaultCerts, filepath.Join(certsDir, trustStoreName)); err != nil {
+			return nil, err
+		}
+		setup.Files[certsDir] = ContainerCertsPath
+		javaOptions = append(javaOptions, trustStoreOptions(ContainerCertsPath+"/"+trustStoreName)...)
+	}
+
+	if mavenSettings := globals.Config.Compile.MavenSettings; mavenSettings != "" {
+		m2Dir := filepath.Join(workDir, "m2")
+		if err := os.MkdirAll(m2Dir, 0755); err != nil {
+			return nil, err
+		}
+		if err := utils.CopyFile(mavenSettings, filepath.Join(m2Dir, "settings.xml")); err != nil {
+			return nil, fmt.Errorf("failed to stage Maven settings: %w", err)
+		}
+		setup.Files[m2Dir] = ContainerM2Path
+		setup.Env = append(setup.Env, "MAVEN_ARGS=--settings "+ContainerM2Path+"/settings.xml")
+	}
+
+	if initScripts := globals.Config.Compile.GradleInitScripts; len(initScripts) > 0 {
+		gradleHomeDir := filepath.Join(workDir, "gradle-home")
+		initDir := filepath.Join(gradleHomeDir, "init.d")
+		if err := os.MkdirAll(initDir, 0755); err != nil {
+			return nil, err
+		}
+		for _, script := range initScripts {
+			if err := utils.CopyFile(script, filepath.Join(initDir, filepath.Base(script))); err != nil {
+				return nil, fmt.Errorf("failed to stage Gradle init script: %w", err)
+			}
+		}
+		setup.Files[gradleHomeDir] = ContainerGradleHomePath
+		setup.Env = append(setup.Env, "GRADLE_USER_HOME="+ContainerGradleHomePath)
+	}
+
+	if len(javaOptions) > 0 {
+		setup.Env = append(setup.Env, "JAVA_TOOL_OPTIONS="+strings.Join(javaOptions, " "))
+	}
+	return setup, nil
+}
+
+// ForNative passes proxy settings and network.ca_bundle to the JVM of a native build from javaHome,
+// Maven and Gradle keep using the user's own settings
+func ForNative(workDir, javaHome string) (*Setup, error) {
+	setup := &Setup{}
+	javaOptions := JavaProxyOptions()
+
+	if caBundle := globals.Config.Network.CABundle; caBundle != "" {
+		trustStorePath := filepath.Join(workDir, trustStoreName)
+		var defaultPaths []string
+		for _, relative := range jdkTrustStorePaths {
+			defaultPaths = append(defaultPaths, filepath.Join(javaHome, filepath.FromSlash(relative)))
+		}
+		defaultCerts := readDefaultCerts(defaultPaths, os.ReadFile)
+		if err := WriteTrustStore(caBundle, defaultCerts, trustStorePath); err != nil {
+			return nil, err
+		}
+		javaOptions = append(javaOptions, trustStoreOptions(trustStorePath)...)
+	}
+
+	if len(javaOptions) > 0 {
+		if existing := os.Getenv("JAVA_TOOL_OPTIONS"); existing != "" {
+			javaOptions = append([]string{existing}, javaOptions...)
+		}
+		setup.Env = append(setup.Env, "JAVA_TOOL_OPTIONS="+strings.Join(javaOptions, " "))
+	}
+	return setup, nil
+}
+
+// ProxyEnv returns proxy variables of the current environment
+func ProxyEnv() []string {
+	var env []string
+	for _, name := range proxyEnvNames {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// JavaProxyOptions translates HTTP_PROXY, HTTPS_PROXY and NO_PROXY into JVM networking properties,
+// which Maven and Gradle use instead of the environment variables
+func JavaProxyOptions() []string {
+	var options []string
+	options = append(options, javaProxyOptions("http", getProxyEnv("HTTP_PROXY"))...)
+	options = append(options, javaProxyOptions("https", getProxyEnv("HTTPS_PROXY"))...)
+	if len(options) == 0 {
+		return nil
+	}
+
+	var nonProxyHosts []string
+	for _, host := range strings.Split(getProxyEnv("NO_PROXY"), ",") {
+		host = strings.TrimSpace(host)
+		// The JVM doesn't support CIDR ranges
+		if host == "" || strings.Contains(host, "/") {
+			continue
+		}
+		if strings.HasPrefix(host, ".") {
+			host = "*" + host
+		}
+		nonProxyHosts = append(nonProxyHosts, host)
+	}
+	if len(nonProxyHosts) > 0 {
+		// http.nonProxyHosts is used for https as well
+		options = append(options, "-Dhttp.nonProxyHosts="+strings.Join(nonProxyHosts, "|"))
+	}
+	return options
+}
+
+func getProxyEnv(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return os.Getenv(strings.ToLower(name))
+}
+
+func javaProxyOptions(protocol, proxy string) []string {
+	if proxy == "" {
+		return nil
+	}
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return []string{
+		fmt.Sprintf("-D%s.proxyHost=%s", protocol, u.Hostname()),
+		fmt.Sprintf("-D%s.proxyPort=%s", protocol, port),
+	}
+}
+
+// readDefaultCerts returns the certificates of the first default trust store of a JDK found at paths.
+// Without them builds trust network.ca_bundle only, which is enough when all hosts are behind the proxy.
+func readDefaultCerts(paths []string, readFile func(path string) ([]byte, error)) [][]byte {
+	var errs []error
+	for _, path := range paths {
+		data, err := readFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		certs, err := readTrustStoreCerts(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		return certs
+	}
+	logrus.Warnf("Can't read the default trust store of the JDK, builds trust network.ca_bundle only: %s", errors.Join(errs...))
+	return nil
+}
+
+func trustStoreOptions(trustStorePath string) []string {
+	return []string{
+		"-Djavax.net.ssl.trustStore=" + trustStorePath,
+		"-Djavax.net.ssl.trustStoreType=JKS",
+		"-Djavax.net.ssl.trustStorePassword=" + TrustStorePassword,
+	}
+}
@@ -0,0 +1,139 @@
+package build_env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/seqrateam/seqra/internal/globals"
+)
+
+const (
+	DependencyCacheNone   = "none"
+	DependencyCacheVolume = "volume"
+	DependencyCacheBind   = "bind"
+
+	defaultDependencyCacheVolume = "seqra-dependency-cache"
+
+	containerMavenRepositoryPath = ContainerM2Path + "/repository"
+	containerGradleCachesPath    = ContainerGradleHomePath + "/caches"
+	containerGradleWrapperPath   = ContainerGradleHomePath + "/wrapper"
+
+	// Maven and Gradle can't write to read-only caches, they are used as read-only tails instead
+	containerMavenReadOnlyPath  = "/data/m2-ro/repository"
+	containerGradleReadOnlyPath = "/data/gradle-ro/caches"
+)
+
+// dependencyCacheDir is a part of the dependency cache mounted into the autobuilder container
+type dependencyCacheDir struct {
+	volumeSuffix string
+	// gradle tells whether hostSubdir is relative to the Gradle or the Maven directory
+	gradle        bool
+	hostSubdir    string
+	containerPath string
+	// readOnlyPath is used instead of containerPath in read-only mode, empty if the dir isn't mounted then
+	readOnlyPath string
+}
+
+var dependencyCacheDirs = []dependencyCacheDir{
+	{volumeSuffix: "maven", hostSubdir: "repository", containerPath: containerMavenRepositoryPath, readOnlyPath: containerMavenReadOnlyPath},
+	{volumeSuffix: "gradle-caches", gradle: true, hostSubdir: "caches", containerPath: containerGradleCachesPath, readOnlyPath: containerGradleReadOnlyPath},
+	{volumeSuffix: "gradle-wrapper", gradle: true, hostSubdir: "wrapper", containerPath: containerGradleWrapperPath},
+}
+
+func (dir dependencyCacheDir) hostPath(cache globals.DependencyCache, home string) string {
+	if dir.gradle {
+		if cache.GradleDir != "" {
+			return filepath.Join(cache.GradleDir, dir.hostSubdir)
+		}
+		return filepath.Join(home, ".gradle", dir.hostSubdir)
+	}
+	if cache.MavenDir != "" {
+		return filepath.Join(cache.MavenDir, dir.hostSubdir)
+	}
+	return filepath.Join(home, ".m2", dir.hostSubdir)
+}
+
+// ForDependencyCache makes Maven and Gradle in the autobuilder container reuse dependencies
+// between runs, according to compile.dependency_cache. Files are staged in workDir.
+func ForDependencyCache(workDir string) (*Setup, error) {
+	cache := globals.Config.Compile.DependencyCache
+	setup := &Setup{Files: make(map[string]string)}
+
+	switch cache.Mode {
+	case "", DependencyCacheNone:
+		return setup, nil
+	case DependencyCacheVolume:
+		volume := cache.Volume
+		if volume == "" {
+			volume = defaultDependencyCacheVolume
+		}
+		for _, dir := range dependencyCacheDirs {
+			target := dir.containerPath
+			if cache.ReadOnly {
+				if dir.readOnlyPath == "" {
+					continue
+				}
+				target = dir.readOnlyPath
+			}
+			setup.Mounts = append(setup.Mounts, mount.Mount{
+				Type:     mount.TypeVolume,
+				Source:   volume + "-" + dir.volumeSuffix,
+				Target:   target,
+				ReadOnly: cache.ReadOnly,
+			})
+			if cache.ReadOnly {
+				continue
+			}
+			// New volumes are owned by root, copying an empty directory over the mount point
+			// hands it to the current user, whom the build runs as (CONTAINER_UID)
+			ownerDir := filepath.Join(workDir, "dependency-cache", dir.volumeSuffix)
+			if err := os.MkdirAll(ownerDir, 0755); err != nil {
+				return nil, err
+			}
+			setup.Files[ownerDir] = target
+		}
+	case DependencyCacheBind:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dependencyCacheDirs {
+			target := dir.containerPath
+			if cache.ReadOnly {
+				if dir.readOnlyPath == "" {
+					continue
+				}
+				target = dir.readOnlyPath
+			}
+			source := dir.hostPath(cache, home)
+			// Docker creates missing bind sources as root, create them as the current user instead
+			if err := os.MkdirAll(source, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create dependency cache directory: %w", err)
+			}
+			setup.Mounts = append(setup.Mounts, mount.Mount{
+				Type:     mount.TypeBind,
+				Source:   source,
+				Target:   target,
+				ReadOnly: cache.ReadOnly,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("compile.dependency_cache.mode must be one of %q, %q, %q", DependencyCacheNone, DependencyCacheVolume, DependencyCacheBind)
+	}
+
+	if cache.ReadOnly {
+		setup.Env = append(setup.Env,
+			"MAVEN_OPTS=-Dmaven.repo.local.tail="+containerMavenReadOnlyPath,
+			"GRADLE_RO_DEP_CACHE="+containerGradleReadOnlyPath,
+		)
+	} else {
+		setup.Env = append(setup.Env,
+			"MAVEN_OPTS=-Dmaven.repo.local="+containerMavenRepositoryPath,
+			"GRADLE_USER_HOME="+ContainerGradleHomePath,
+		)
+	}
+	return setup, nil
+}
@@ -0,0 +1,196 @@
+package build_env
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jdkTrustStorePaths are the default trust stores relative to a Java home, the second one is of Java 8 JDKs
+var jdkTrustStorePaths = []string{"lib/security/cacerts", "jre/lib/security/cacerts"}
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidCertBag       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509CertBagID = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+)
+
+// readTrustStoreCerts returns the certificates of a JKS or a password-less PKCS12 trust store,
+// these are the formats of cacerts before and since Java 18
+func readTrustStoreCerts(data []byte) ([][]byte, error) {
+	if len(data) >= 4 && binary.BigEndian.Uint32(data) == 0xFEEDFEED {
+		return readJKSCerts(data)
+	}
+	return readPKCS12Certs(data)
+}
+
+func readJKSCerts(data []byte) ([][]byte, error) {
+	r := bytes.NewReader(data)
+	readUint32 := func() (uint32, error) {
+		var v uint32
+		err := binary.Read(r, binary.BigEndian, &v)
+		return v, err
+	}
+	readBytes := func(n int64) ([]byte, error) {
+		if n > int64(r.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, err
+	}
+	skipUTF := func() error {
+		var n uint16
+		if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+			return err
+		}
+		_, err := readBytes(int64(n))
+		return err
+	}
+	readCert := func(version uint32) ([]byte, error) {
+		if version == 2 {
+			if err := skipUTF(); err != nil {
+				return nil, err
+			}
+		}
+		n, err := readUint32()
+		if err != nil {
+			return nil, err
+		}
+		return readBytes(int64(n))
+	}
+
+	if _, err := readUint32(); err != nil {
+		return nil, err
+	}
+	version, err := readUint32()
+	if err != nil {
+		return nil, err
+	}
+	if version != 1 && version != 2 {
+		return nil, fmt.Errorf("unsupported JKS version %d", version)
+	}
+	count, err := readUint32()
+	if err != nil {
+		return nil, err
+	}
+
+	var certs [][]byte
+	for i := uint32(0); i < count; i++ {
+		tag, err := readUint32()
+		if err != nil {
+			return nil, err
+		}
+		if err := skipUTF(); err != nil {
+			return nil, err
+		}
+		if _, err := readBytes(8); err != nil {
+			return nil, err
+		}
+		switch tag {
+		case 1: // private key entry, its chain isn't trusted
+			n, err := readUint32()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := readBytes(int64(n)); err != nil {
+				return nil, err
+			}
+			chainLength, err := readUint32()
+			if err != nil {
+				return nil, err
+			}
+			for j := uint32(0); j < chainLength; j++ {
+				if _, err := readCert(version); err != nil {
+					return nil, err
+				}
+			}
+		case 2: // trusted certificate entry
+			cert, err := readCert(version)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		default:
+			return nil, fmt.Errorf("unsupported JKS entry type %d", tag)
+		}
+	}
+	return certs, nil
+}
+
+type pkcs12ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type pkcs12PFX struct {
+	Version  int
+	AuthSafe pkcs12ContentInfo
+	MacData  asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs12SafeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue `asn1:"tag:0,explicit"`
+	Attributes asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs12CertBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+func readPKCS12Certs(data []byte) ([][]byte, error) {
+	var pfx pkcs12PFX
+	if _, err := asn1.Unmarshal(data, &pfx); err != nil {
+		return nil, fmt.Errorf("neither a JKS nor a PKCS12 trust store: %w", err)
+	}
+	authSafe, err := pkcs12Data(pfx.AuthSafe)
+	if err != nil {
+		return nil, err
+	}
+	var contents []pkcs12ContentInfo
+	if _, err := asn1.Unmarshal(authSafe, &contents); err != nil {
+		return nil, err
+	}
+
+	var certs [][]byte
+	for _, content := range contents {
+		safeContents, err := pkcs12Data(content)
+		if err != nil {
+			return nil, err
+		}
+		var bags []pkcs12SafeBag
+		if _, err := asn1.Unmarshal(safeContents, &bags); err != nil {
+			return nil, err
+		}
+		for _, bag := range bags {
+			if !bag.ID.Equal(oidCertBag) {
+				continue
+			}
+			var certBag pkcs12CertBag
+			if _, err := asn1.Unmarshal(bag.Value.Bytes, &certBag); err != nil {
+				return nil, err
+			}
+			if certBag.ID.Equal(oidX509CertBagID) {
+				certs = append(certs, certBag.Data)
+			}
+		}
+	}
+	return certs, nil
+}
+
+// pkcs12Data returns the content of an unencrypted content info
+func pkcs12Data(content pkcs12ContentInfo) ([]byte, error) {
+	if !content.ContentType.Equal(oidData) {
+		return nil, errors.New("encrypted PKCS12 trust stores aren't supported")
+	}
+	var data []byte
+	if _, err := asn1.Unmarshal(content.Content.Bytes, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
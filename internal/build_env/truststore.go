@@ -0,0 +1,82 @@
+package build_env
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+)
+
+// TrustStorePassword protects the integrity of generated trust stores only, they contain no secrets
+const TrustStorePassword = "changeit"
+
+// WriteTrustStore converts PEM certificates from caBundlePath into a JKS trust store readable by any JVM.
+// The store also holds defaultCerts, so that public hosts stay trusted besides the custom CA.
+func WriteTrustStore(caBundlePath string, defaultCerts [][]byte, trustStorePath string) error {
+	data, err := os.ReadFile(caBundlePath)
+	if err != nil {
+		return fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	var certs [][]byte
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("invalid certificate in CA bundle %s: %w", caBundlePath, err)
+		}
+		certs = append(certs, block.Bytes)
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificates found in CA bundle %s", caBundlePath)
+	}
+
+	return os.WriteFile(trustStorePath, encodeJKS(append(defaultCerts, certs...), TrustStorePassword), 0644)
+}
+
+// encodeJKS writes trusted certificate entries in the JKS format:
+// magic, version, entry count, entries and a SHA-1 digest keyed by the password
+func encodeJKS(certs [][]byte, password string) []byte {
+	var buf bytes.Buffer
+	writeUint32 := func(v uint32) {
+		_ = binary.Write(&buf, binary.BigEndian, v)
+	}
+	writeUTF := func(s string) {
+		_ = binary.Write(&buf, binary.BigEndian, uint16(len(s)))
+		buf.WriteString(s)
+	}
+
+	writeUint32(0xFEEDFEED)
+	writeUint32(2)
+	writeUint32(uint32(len(certs)))
+
+	timestamp := time.Now().UnixMilli()
+	for i, cert := range certs {
+		writeUint32(2) // trusted certificate entry
+		writeUTF(fmt.Sprintf("seqra-ca-%d", i))
+		_ = binary.Write(&buf, binary.BigEndian, timestamp)
+		writeUTF("X.509")
+		writeUint32(uint32(len(cert)))
+		buf.Write(cert)
+	}
+
+	hash := sha1.New()
+	for _, c := range password {
+		hash.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	hash.Write([]byte("Mighty Aphrodite"))
+	hash.Write(buf.Bytes())
+	buf.Write(hash.Sum(nil))
+
+	return buf.Bytes()
+}
@@ -0,0 +1,159 @@
+package build_failure
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type Cause string
+
+const (
+	CauseMissingJDK       Cause = "missing or unsuitable JDK"
+	CauseDependencies     Cause = "dependency resolution failed"
+	CauseCompilation      Cause = "compilation errors"
+	CauseUnsupportedBuild Cause = "unsupported build system"
+	CauseUnknown          Cause = "build failed"
+)
+
+// Diagnosis is a short explanation of a failed build
+type Diagnosis struct {
+	Cause Cause
+	Hint  string
+	// Errors are the most relevant error lines of the build output
+	Errors []string
+}
+
+// maxErrors bounds the number of error lines in a diagnosis
+const maxErrors = 15
+
+var causePatterns = []struct {
+	cause    Cause
+	hint     string
+	patterns []*regexp.Regexp
+}{
+	{
+		CauseMissingJDK,
+		"Install the JDK required by the project or choose one with --java-home",
+		compile(
+			`JAVA_HOME is not (defined|set) correctly`,
+			`No compiler is provided in this environment`,
+			`invalid (target|source) release`,
+			`release version \d+ not supported`,
+			`Unsupported class file major version`,
+			`No matching toolchains? found`,
+			`Cannot find a Java installation`,
+			`Could not find a Java installation`,
+			`java: command not found`,
+		),
+	},
+	{
+		CauseDependencies,
+		"Check access to the Maven and Gradle repositories: proxy, certificates and credentials",
+		compile(
+			`Could not resolve dependencies`,
+			`Could not resolve all (files|dependencies|artifacts) for configuration`,
+			`Could not transfer artifact`,
+			`Could not find artifact`,
+			`Non-resolvable (parent POM|import POM)`,
+			`Could not (GET|HEAD) '`,
+			`Could not resolve plugin`,
+			`Plugin .* could not be resolved`,
+			`PKIX path building failed`,
+			`Unknown host`,
+		),
+	},
+	{
+		CauseCompilation,
+		"Make sure the project compiles with its own build tool",
+		compile(
+			`COMPILATION ERROR`,
+			`Compilation failed`,
+			`Execution failed for task '.*:compile\w*(Java|Kotlin)'`,
+			`\.java:\[\d+,\d+\]`,
+			`\.java:\d+: error:`,
+		),
+	},
+	{
+		CauseUnsupportedBuild,
+		"Only Maven and Gradle projects are supported",
+		compile(
+			`(No|Unsupported|Unknown) build system`,
+			`(Can't|Cannot|Unable to) detect (the )?build system`,
+			`does not contain a (Maven|Gradle) project`,
+		),
+	},
+}
+
+func compile(patterns ...string) []*regexp.Regexp {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		compiled = append(compiled, regexp.MustCompile(`(?i)`+pattern))
+	}
+	return compiled
+}
+
+// errorLinePattern matches error lines of Maven, Gradle and javac
+var errorLinePattern = regexp.MustCompile(`\[ERROR]|\bERROR\b|FAILURE:|What went wrong|error:|Exception`)
+
+// Diagnose classifies a build failure by the tail of its output and the build files of the project
+func Diagnose(projectRoot string, lines []string) Diagnosis {
+	diagnosis := Diagnosis{Cause: CauseUnknown, Errors: errorLines(lines)}
+
+	for _, causePattern := range causePatterns {
+		if matchesAny(lines, causePattern.patterns) {
+			diagnosis.Cause = causePattern.cause
+			diagnosis.Hint = causePattern.hint
+			return diagnosis
+		}
+	}
+
+	if projectRoot != "" && DetectBuildSystem(projectRoot) == "" {
+		diagnosis.Cause = CauseUnsupportedBuild
+		diagnosis.Hint = "No pom.xml or Gradle build file found in the project root, only Maven and Gradle projects are supported"
+	}
+	return diagnosis
+}
+
+// DetectBuildSystem returns maven or gradle by the build files in the project root, or "" if there are none
+func DetectBuildSystem(projectRoot string) string {
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(projectRoot, name))
+		return err == nil
+	}
+	switch {
+	case exists("pom.xml"):
+		return "maven"
+	case exists("build.gradle"), exists("build.gradle.kts"), exists("settings.gradle"), exists("settings.gradle.kts"):
+		return "gradle"
+	}
+	return ""
+}
+
+func matchesAny(lines []string, patterns []*regexp.Regexp) bool {
+	for _, line := range lines {
+		for _, pattern := range patterns {
+			if pattern.MatchString(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errorLines returns the first error lines of the output, those closest to the cause
+func errorLines(lines []string) []string {
+	var errors []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || !errorLinePattern.MatchString(line) {
+			continue
+		}
+		errors = append(errors, line)
+		if len(errors) == maxErrors {
+			break
+		}
+	}
+	return errors
+}
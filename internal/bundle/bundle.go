@@ -0,0 +1,183 @@
+package bundle
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/seqrateam/seqra/internal/container_run"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+const (
+	ManifestFile    = "manifest.json"
+	ImagesFile      = "images.tar"
+	RulesFile       = "rules.tar"
+	AutobuilderFile = "autobuilder.jar"
+	AnalyzerFile    = "analyzer.jar"
+)
+
+// Manifest describes the content of an offline bundle
+type Manifest struct {
+	SeqraVersion       string                        `json:"seqra_version"`
+	AnalyzerVersion    string                        `json:"analyzer_version"`
+	AutobuilderVersion string                        `json:"autobuilder_version"`
+	RulesVersion       string                        `json:"rules_version"`
+	Images             []container_run.ExportedImage `json:"images"`
+	// Checksums maps bundle entries to their SHA-256 digests
+	Checksums map[string]string `json:"checksums"`
+}
+
+// Write creates a bundle with the manifest followed by files, given as a map from entry names to paths
+func Write(bundlePath string, manifest Manifest, files map[string]string) (err error) {
+	names := make([]string, 0, len(files))
+	manifest.Checksums = make(map[string]string)
+	for name, path := range files {
+		digest, err := utils.FileSHA256(path)
+		if err != nil {
+			return err
+		}
+		manifest.Checksums[name] = digest
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := bundlePath + ".temp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = out.Close()
+		_ = os.Remove(tmpPath)
+	}()
+
+	tw := tar.NewWriter(out)
+	if err := tw.WriteHeader(&tar.Header{Name: ManifestFile, Mode: 0644, Size: int64(len(manifestData))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifestData); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := writeFileEntry(tw, name, files[name]); err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, bundlePath)
+}
+
+func writeFileEntry(tw *tar.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Read extracts the bundle entries into destDir and verifies them against the manifest checksums
+func Read(bundlePath, destDir string) (*Manifest, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	tr := tar.NewReader(f)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	if hdr.Name != ManifestFile {
+		return nil, fmt.Errorf("not a seqra bundle: the first entry is %q instead of %s", hdr.Name, ManifestFile)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle manifest: %w", err)
+	}
+
+	extracted := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		expectedDigest, ok := manifest.Checksums[hdr.Name]
+		if !ok || filepath.Base(hdr.Name) != hdr.Name {
+			return nil, fmt.Errorf("unexpected bundle entry: %s", hdr.Name)
+		}
+
+		digest, err := extractEntry(tr, filepath.Join(destDir, hdr.Name))
+		if err != nil {
+			return nil, err
+		}
+		if digest != expectedDigest {
+			return nil, fmt.Errorf("checksum mismatch for bundle entry %s: expected %s, got %s", hdr.Name, expectedDigest, digest)
+		}
+		extracted[hdr.Name] = true
+	}
+
+	for name := range manifest.Checksums {
+		if !extracted[name] {
+			return nil, fmt.Errorf("bundle entry is missing: %s", name)
+		}
+	}
+	return &manifest, nil
+}
+
+func extractEntry(r io.Reader, path string) (digest string, err error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if cerr := out.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,453 @@
+package container_run
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+// SeqraImage is a local Docker image of the seqra analyzer or autobuilder
+type SeqraImage struct {
+	ID         string
+	References []string
+	Size       int64
+	Current    bool
+}
+
+func seqraRepositories() []string {
+	return []string{
+		globals.AnalyzerDocker,
+		globals.AutobuilderDocker,
+		utils.GetAnalyzerRepository(),
+		utils.GetAutobuilderRepository(),
+	}
+}
+
+// ListSeqraImages returns seqra images present in the local Docker daemon.
+// Images referenced by one of currentImageLinks are marked as current.
+func ListSeqraImages(currentImageLinks []string) (images []SeqraImage, err error) {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, cli.Close())
+	}()
+
+	current := make(map[string]bool)
+	for _, link := range currentImageLinks {
+		current[link] = true
+		if imported, ok := importedImageFor(link); ok {
+			current[imported.ID] = true
+		}
+	}
+
+	summaries, err := cli.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, summary := range summaries {
+		var references []string
+		references = append(references, summary.RepoTags...)
+		references = append(references, summary.RepoDigests...)
+
+		seqraImage := SeqraImage{ID: summary.ID, Size: summary.Size, Current: current[summary.ID]}
+		for _, reference := range references {
+			if !isSeqraReference(reference) {
+				continue
+			}
+			seqraImage.References = append(seqraImage.References, reference)
+			if current[reference] {
+				seqraImage.Current = true
+			}
+		}
+		if len(seqraImage.References) > 0 {
+			images = append(images, seqraImage)
+		}
+	}
+	return images, nil
+}
+
+func isSeqraReference(reference string) bool {
+	for _, repository := range seqraRepositories() {
+		if strings.HasPrefix(reference, repository+":") || strings.HasPrefix(reference, repository+"@") {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveImage removes the image with all its tags from the local Docker daemon
+func RemoveImage(imageID string) (err error) {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, cli.Close())
+	}()
+
+	_, err = cli.ImageRemove(ctx, imageID, image.RemoveOptions{Force: true, PruneChildren: true})
+	return err
+}
+
+// ExportedImage describes an image saved into an offline bundle
+type ExportedImage struct {
+	// Reference is the tag reference the image is saved and loaded with
+	Reference string `json:"reference"`
+	// PinnedReference is the digest reference the image is used with, if it is pinned
+	PinnedReference string `json:"pinned_reference,omitempty"`
+	ID              string `json:"id"`
+	// RepoDigests are the registry digests of the image, they must contain the digest of PinnedReference
+	RepoDigests []string `json:"repo_digests,omitempty"`
+}
+
+// SaveImages writes images to out in the `docker save` format and fills their IDs
+func SaveImages(images []ExportedImage, out io.Writer) (err error) {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, cli.Close())
+	}()
+
+	var references []string
+	for i := range images {
+		exported := &images[i]
+		usedReference := exported.Reference
+		if exported.PinnedReference != "" {
+			usedReference = exported.PinnedReference
+		}
+		imageID, repoDigests, err := inspectImage(ctx, cli, usedReference)
+		if err != nil {
+			return fmt.Errorf("image %s isn't available locally: %w", usedReference, err)
+		}
+		if exported.PinnedReference != "" && !hasDigest(exported.PinnedReference, repoDigests) {
+			return fmt.Errorf("image %s doesn't have the pinned digest, local image has digests %v", usedReference, repoDigests)
+		}
+		exported.ID = imageID
+		exported.RepoDigests = repoDigests
+
+		// Images pulled by digest may have no tag, and untagged images lose their name in `docker save`
+		if taggedID, err := resolveImageID(ctx, cli, exported.Reference); err != nil || taggedID != imageID {
+			if err := cli.ImageTag(ctx, imageID, exported.Reference); err != nil {
+				return fmt.Errorf("failed to tag image %s: %w", exported.Reference, err)
+			}
+		}
+		references = append(references, exported.Reference)
+	}
+
+	reader, err := cli.ImageSave(ctx, references)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, reader.Close())
+	}()
+
+	_, err = io.Copy(out, reader)
+	return err
+}
+
+// LoadImages loads images saved by SaveImages and checks that they got the expected IDs.
+// Pinned references are recorded, so that the loaded images are used for them without network access.
+func LoadImages(in io.Reader, images []ExportedImage) (err error) {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, cli.Close())
+	}()
+
+	response, err := cli.ImageLoad(ctx, in, client.ImageLoadWithQuiet(true))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, response.Body.Close())
+	}()
+	if _, err := io.Copy(io.Discard, response.Body); err != nil {
+		return err
+	}
+
+	for _, exported := range images {
+		imageInspect, err := cli.ImageInspect(ctx, exported.Reference)
+		if err != nil {
+			return fmt.Errorf("image %s wasn't loaded: %w", exported.Reference, err)
+		}
+		if imageInspect.ID != exported.ID {
+			return fmt.Errorf("image %s has ID %s, expected %s", exported.Reference, imageInspect.ID, exported.ID)
+		}
+		if exported.PinnedReference != "" {
+			if !hasDigest(exported.PinnedReference, exported.RepoDigests) {
+				return fmt.Errorf("image %s isn't exported with the pinned digest of %s", exported.Reference, exported.PinnedReference)
+			}
+			if err := recordImportedImage(exported.PinnedReference, importedImage{ID: exported.ID, RepoDigests: exported.RepoDigests}); err != nil {
+				return err
+			}
+		}
+		logrus.Debugf("Loaded image %s: %s", exported.Reference, exported.ID)
+	}
+	return nil
+}
+
+func resolveImageID(ctx context.Context, cli *client.Client, imageLink string) (string, error) {
+	imageID, _, err := inspectImage(ctx, cli, imageLink)
+	return imageID, err
+}
+
+// inspectImage returns the ID and the registry digests of a local or imported image
+func inspectImage(ctx context.Context, cli *client.Client, imageLink string) (string, []string, error) {
+	imageInspect, err := cli.ImageInspect(ctx, imageLink)
+	if err == nil {
+		return imageInspect.ID, imageInspect.RepoDigests, nil
+	}
+	if imported, ok := importedImageFor(imageLink); ok {
+		return imported.ID, imported.RepoDigests, nil
+	}
+	return "", nil, err
+}
+
+// hasDigest reports whether one of repoDigests has the digest imageLink is pinned to
+func hasDigest(imageLink string, repoDigests []string) bool {
+	expectedDigest := utils.GetImageDigest(imageLink)
+	if expectedDigest == "" {
+		return false
+	}
+	for _, repoDigest := range repoDigests {
+		if utils.GetImageDigest(repoDigest) == expectedDigest {
+			return true
+		}
+	}
+	return false
+}
+
+// Images loaded with `docker load` have no repo digests, so the digest references
+// of imported images are mapped to the IDs and digests verified during the import
+type importedImage struct {
+	ID          string   `json:"id"`
+	RepoDigests []string `json:"repo_digests,omitempty"`
+}
+
+// UnmarshalJSON also accepts the plain image ID recorded by earlier versions,
+// such records have no digests and fail verification until the bundle is imported again
+func (i *importedImage) UnmarshalJSON(data []byte) error {
+	var imageID string
+	if err := json.Unmarshal(data, &imageID); err == nil {
+		*i = importedImage{ID: imageID}
+		return nil
+	}
+	type plain importedImage
+	return json.Unmarshal(data, (*plain)(i))
+}
+
+func importedImagesPath() (string, error) {
+	seqraHomePath, err := utils.GetSeqraHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(seqraHomePath, "imported_images.json"), nil
+}
+
+func readImportedImages() (map[string]importedImage, error) {
+	path, err := importedImagesPath()
+	if err != nil {
+		return nil, err
+	}
+	imported := make(map[string]importedImage)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return imported, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return nil, err
+	}
+	return imported, nil
+}
+
+func recordImportedImage(pinnedReference string, image importedImage) error {
+	imported, err := readImportedImages()
+	if err != nil {
+		return err
+	}
+	imported[pinnedReference] = image
+
+	data, err := json.MarshalIndent(imported, "", "  ")
+	if err != nil {
+		return err
+	}
+	path, err := importedImagesPath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func importedImageFor(imageLink string) (importedImage, bool) {
+	if utils.GetImageDigest(imageLink) == "" {
+		return importedImage{}, false
+	}
+	imported, err := readImportedImages()
+	if err != nil {
+		logrus.Debugf("Can't read imported images: %s", err)
+		return importedImage{}, false
+	}
+	image, ok := imported[imageLink]
+	return image, ok
+}
+
+// ReadImageFile reads a regular file of the image, pulling the image according to the pull policy.
+// Variables in path, like $JAVA_HOME, are expanded with the environment of the image.
+func ReadImageFile(imageLink, path string) (data []byte, err error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, cli.Close())
+	}()
+
+	ctx := context.Background()
+	reference := ensureImage(cli, imageLink)
+	imageInspect, err := cli.ImageInspect(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	var env []string
+	if imageInspect.Config != nil {
+		env = imageInspect.Config.Env
+	}
+	path = os.Expand(path, func(name string) string {
+		for _, variable := range env {
+			if value, ok := strings.CutPrefix(variable, name+"="); ok {
+				return value
+			}
+		}
+		return ""
+	})
+
+	// The container is only created to copy the file from it and is never started
+	resp, err := cli.ContainerCreate(ctx, &container.Config{Image: reference}, nil, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true, RemoveVolumes: true}))
+	}()
+
+	stat, err := cli.ContainerStatPath(ctx, resp.ID, path)
+	if err != nil {
+		return nil, err
+	}
+	if stat.LinkTarget != "" {
+		path = stat.LinkTarget
+	}
+	reader, _, err := cli.CopyFromContainer(ctx, resp.ID, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, reader.Close())
+	}()
+
+	tr := tar.NewReader(reader)
+	header, err := tr.Next()
+	if err != nil {
+		return nil, err
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%s isn't a regular file", path)
+	}
+	return io.ReadAll(tr)
+}
+
+// ImageOutput runs the image with flags and env without network and returns what it printed, e.g. its --help.
+// The exit code isn't checked, tools exit with different codes after printing their help.
+func ImageOutput(ctx context.Context, imageLink string, flags, env []string) (output []byte, err error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, cli.Close())
+	}()
+
+	reference := ensureImage(cli, imageLink)
+	config := &container.Config{Image: reference, Cmd: flags, Env: env}
+	resp, err := cli.ContainerCreate(ctx, config, &container.HostConfig{NetworkMode: "none"}, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true, RemoveVolumes: true}))
+	}()
+
+	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return nil, err
+	}
+	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return nil, err
+		}
+	case <-statusCh:
+	}
+
+	logs, err := cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, logs.Close())
+	}()
+	var out bytes.Buffer
+	if _, err := stdcopy.StdCopy(&out, &out, logs); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// DockerVersion returns the version of the Docker daemon
+func DockerVersion() (version string, err error) {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err = errors.Join(err, cli.Close())
+	}()
+
+	serverVersion, err := cli.ServerVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (API %s, %s/%s)", serverVersion.Version, serverVersion.APIVersion, serverVersion.Os, serverVersion.Arch), nil
+}
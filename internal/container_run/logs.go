@@ -0,0 +1,66 @@
+package container_run
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/utils/log"
+)
+
+// followLogs writes the container output to the log at debug level line by line as it is produced
+// and feeds it to the progress indicator. The returned channel is closed when the output ends.
+func followLogs(ctx context.Context, cli *client.Client, containerID, taskName string, progress *log.ProgressLine) <-chan struct{} {
+	done := make(chan struct{})
+
+	out, err := cli.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+	})
+	if err != nil {
+		logrus.Warnf("Failed to follow container logs: %v", err)
+		close(done)
+		return done
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(writer, writer, out)
+		_ = out.Close()
+		_ = writer.CloseWithError(err)
+	}()
+
+	go func() {
+		defer close(done)
+		log.StreamLines(reader, logrus.WithField("container", taskName), progress)
+	}()
+	return done
+}
+
+// WriteContainerLogs writes the whole output of a container, e.g. one kept after a failure
+func WriteContainerLogs(containerID string, out io.Writer) (err error) {
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, cli.Close())
+	}()
+
+	logs, err := cli.ContainerLogs(ctx, containerID, container.LogsOptions{ShowStdout: true, ShowStderr: true, Timestamps: true})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, logs.Close())
+	}()
+	_, err = stdcopy.StdCopy(out, out, logs)
+	return err
+}
@@ -0,0 +1,218 @@
+package container_run
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+// boundOutput is a host directory bind-mounted to collect outputs of the container
+type boundOutput struct {
+	hostDir string
+	target  string
+	// files maps container paths under target to their final host paths
+	files map[string]string
+}
+
+// UseBindMounts tells whether inputs and outputs are bind-mounted instead of copied.
+// Bind mounts need the Docker daemon to see the host file system, so remote daemons always use copy.
+func UseBindMounts() bool {
+	if globals.Config.Mount.Mode != globals.MountBind {
+		return false
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to create docker client: %s", err)
+	}
+	defer func() {
+		_ = cli.Close()
+	}()
+	return isLocalDaemon(cli.DaemonHost())
+}
+
+func isLocalDaemon(daemonHost string) bool {
+	u, err := url.Parse(daemonHost)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "unix", "npipe":
+		return true
+	case "tcp", "http", "https":
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			return true
+		}
+	}
+	return false
+}
+
+// planBindMounts replaces copies with bind mounts: inputs are mounted read-only, outputs are collected
+// in host directories. Copies which overlap mounts already present in hostConfig are kept.
+func planBindMounts(hostConfig *container.HostConfig, copyToContainer, copyFromContainer map[string]string) (map[string]string, []boundOutput, error) {
+	remainingCopyTo := make(map[string]string)
+	for hostPath, containerPath := range copyToContainer {
+		if overlapsMounts(hostConfig.Mounts, containerPath) {
+			remainingCopyTo[hostPath] = containerPath
+			continue
+		}
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   hostPath,
+			Target:   containerPath,
+			ReadOnly: true,
+		})
+	}
+
+	// Outputs are mounted through their parent directory, so that the container can create them.
+	// A parent which also holds inputs can't be mounted, the output itself is mounted as a directory then.
+	outputsByTarget := make(map[string]map[string]string)
+	for containerPath, hostPath := range copyFromContainer {
+		target := path.Dir(containerPath)
+		if overlapsMounts(hostConfig.Mounts, target) {
+			target = containerPath
+		}
+		if outputsByTarget[target] == nil {
+			outputsByTarget[target] = make(map[string]string)
+		}
+		outputsByTarget[target][containerPath] = hostPath
+	}
+
+	var outputs []boundOutput
+	for target, files := range outputsByTarget {
+		var anyHostPath string
+		for _, hostPath := range files {
+			anyHostPath = hostPath
+		}
+		// Next to the result, so that it can be moved in place without copying
+		hostDir, err := os.MkdirTemp(filepath.Dir(anyHostPath), ".seqra-output-*")
+		if err != nil {
+			removeBoundOutputs(outputs)
+			return nil, nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if err := os.Chmod(hostDir, 0755); err != nil {
+			removeBoundOutputs(append(outputs, boundOutput{hostDir: hostDir}))
+			return nil, nil, err
+		}
+		outputs = append(outputs, boundOutput{hostDir: hostDir, target: target, files: files})
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:   mount.TypeBind,
+			Source: hostDir,
+			Target: target,
+		})
+	}
+	return remainingCopyTo, outputs, nil
+}
+
+// addCopyVolumes backs copy destinations with anonymous volumes when the root file system is read-only,
+// since Docker can't copy files into a read-only root file system. Copied files and outputs are backed
+// through their parent directory, parents are mounted before the directories they hold.
+func addCopyVolumes(hostConfig *container.HostConfig, copyToContainer, copyFromContainer map[string]string) {
+	if !hostConfig.ReadonlyRootfs {
+		return
+	}
+	var targets []string
+	for hostPath, containerPath := range copyToContainer {
+		if info, err := os.Stat(hostPath); err == nil && !info.IsDir() {
+			containerPath = path.Dir(containerPath)
+		}
+		targets = append(targets, containerPath)
+	}
+	for containerPath := range copyFromContainer {
+		targets = append(targets, path.Dir(containerPath))
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return len(targets[i]) < len(targets[j])
+	})
+	for _, target := range targets {
+		if overlapsMounts(hostConfig.Mounts, target) {
+			continue
+		}
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{Type: mount.TypeVolume, Target: target})
+	}
+}
+
+// copyExtractDir returns the directory a copy to containerPath is extracted in. With a read-only root file system
+// it is the innermost mount holding containerPath, Docker extracts only into mounts then, "/" otherwise.
+func copyExtractDir(hostConfig *container.HostConfig, containerPath string) string {
+	extractDir := "/"
+	if !hostConfig.ReadonlyRootfs {
+		return extractDir
+	}
+	for _, m := range hostConfig.Mounts {
+		if isPathPrefix(m.Target, containerPath) && len(m.Target) > len(extractDir) {
+			extractDir = m.Target
+		}
+	}
+	return extractDir
+}
+
+func overlapsMounts(mounts []mount.Mount, containerPath string) bool {
+	for _, m := range mounts {
+		if isPathPrefix(m.Target, containerPath) || isPathPrefix(containerPath, m.Target) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPathPrefix(prefix, p string) bool {
+	return p == prefix || strings.HasPrefix(p, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+// collectBoundOutputs moves outputs written by the container to their host paths,
+// with skipMissing outputs a failed container didn't produce are skipped
+func collectBoundOutputs(outputs []boundOutput, skipMissing bool) error {
+	for _, output := range outputs {
+		for containerPath, hostPath := range output.files {
+			if _, err := os.Stat(hostPath); err == nil {
+				return fmt.Errorf("file already exists: %s", hostPath)
+			}
+			relPath := strings.TrimPrefix(strings.TrimPrefix(containerPath, output.target), "/")
+			source := filepath.Join(output.hostDir, filepath.FromSlash(relPath))
+			if _, err := os.Stat(source); err != nil {
+				if skipMissing {
+					continue
+				}
+				return fmt.Errorf("container didn't produce %s: %w", containerPath, err)
+			}
+			if err := moveOutput(source, hostPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func moveOutput(source, hostPath string) error {
+	if err := os.Rename(source, hostPath); err == nil {
+		return nil
+	}
+	// Different file systems
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return utils.CopyDir(source, hostPath)
+	}
+	return utils.CopyFile(source, hostPath)
+}
+
+func removeBoundOutputs(outputs []boundOutput) {
+	for _, output := range outputs {
+		_ = os.RemoveAll(output.hostDir)
+	}
+}
@@ -0,0 +1,141 @@
+package container_run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/phase"
+)
+
+// sandboxCopyInputs lays out inputs like a scan: a project directory, a changed classes file and a ruleset
+func sandboxCopyInputs(t *testing.T) (copyToContainer, copyFromContainer map[string]string) {
+	t.Helper()
+	hostDir := t.TempDir()
+	projectDir := filepath.Join(hostDir, "project")
+	rulesDir := filepath.Join(hostDir, "rules")
+	changedClasses := filepath.Join(hostDir, "changed.txt")
+	for _, dir := range []string{projectDir, rulesDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(projectDir, "project.yaml"): "project\n",
+		filepath.Join(rulesDir, "rule.yaml"):      "rule\n",
+		changedClasses:                            "changed\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	copyToContainer = map[string]string{
+		projectDir:     "/data/project",
+		rulesDir:       "/rules",
+		changedClasses: "/data/changed-classes.txt",
+	}
+	copyFromContainer = map[string]string{
+		"/data/reports/report.txt": filepath.Join(t.TempDir(), "report.txt"),
+	}
+	return copyToContainer, copyFromContainer
+}
+
+func TestCopyExtractDirWithSandbox(t *testing.T) {
+	copyToContainer, copyFromContainer := sandboxCopyInputs(t)
+	hostConfig := &container.HostConfig{}
+	ApplySandbox(hostConfig, globals.Sandbox{})
+	addCopyVolumes(hostConfig, copyToContainer, copyFromContainer)
+
+	for _, containerPath := range copyToContainer {
+		extractDir := copyExtractDir(hostConfig, containerPath)
+		if extractDir == "/" {
+			t.Fatalf("copy to %s is extracted into the read-only root file system", containerPath)
+		}
+		volume := false
+		for _, m := range hostConfig.Mounts {
+			volume = volume || (m.Target == extractDir && m.Type == mount.TypeVolume)
+		}
+		if !volume {
+			t.Fatalf("copy to %s is extracted into %s which isn't a volume: %v", containerPath, extractDir, hostConfig.Mounts)
+		}
+	}
+	for _, m := range hostConfig.Mounts {
+		if m.Target == "/data/changed-classes.txt" {
+			t.Fatalf("copied file is shadowed by a volume: %v", hostConfig.Mounts)
+		}
+	}
+
+	if rel := copyRelPath("/data", "/data/project"); rel != "project" {
+		t.Fatalf("unexpected relative path %q", rel)
+	}
+	if rel := copyRelPath("/rules", "/rules"); rel != "." {
+		t.Fatalf("unexpected relative path %q", rel)
+	}
+	if rel := copyRelPath("/", "/data/project"); rel != "data/project" {
+		t.Fatalf("unexpected relative path %q", rel)
+	}
+}
+
+func TestCopyExtractDirWithWritableRootfs(t *testing.T) {
+	copyToContainer, copyFromContainer := sandboxCopyInputs(t)
+	hostConfig := &container.HostConfig{}
+	ApplySandbox(hostConfig, globals.Sandbox{WritableRootfs: true})
+	addCopyVolumes(hostConfig, copyToContainer, copyFromContainer)
+
+	if len(hostConfig.Mounts) != 0 {
+		t.Fatalf("volumes added for a writable root file system: %v", hostConfig.Mounts)
+	}
+	if extractDir := copyExtractDir(hostConfig, "/data/project"); extractDir != "/" {
+		t.Fatalf("unexpected extract directory %s", extractDir)
+	}
+}
+
+// TestRunCopyModeWithSandbox runs a container in copy mode with the default sandbox, it needs a Docker daemon
+func TestRunCopyModeWithSandbox(t *testing.T) {
+	if testing.Short() {
+		t.Skip("needs a Docker daemon")
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Skipf("no Docker client: %s", err)
+	}
+	defer func() {
+		_ = cli.Close()
+	}()
+	if _, err := cli.Ping(context.Background()); err != nil {
+		t.Skipf("no Docker daemon: %s", err)
+	}
+
+	config := globals.Config
+	defer func() {
+		globals.Config = config
+	}()
+	globals.Config.Mount.Mode = globals.MountCopy
+	globals.Config.Pull.Policy = globals.PullMissing
+	globals.Config.Quiet = true
+
+	copyToContainer, copyFromContainer := sandboxCopyInputs(t)
+	hostConfig := &container.HostConfig{}
+	ApplySandbox(hostConfig, globals.Sandbox{})
+
+	task := phase.Begin("Sandbox copy", "", 0)
+	defer task.End()
+	flags := []string{"sh", "-c", "mkdir -p /data/reports && cat /data/project/project.yaml /rules/rule.yaml /data/changed-classes.txt > /data/reports/report.txt"}
+	RunGhcrContainer(task, "busybox:1.36", flags, nil, hostConfig, copyToContainer, copyFromContainer, nil)
+
+	report, err := os.ReadFile(copyFromContainer["/data/reports/report.txt"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(report) != "project\nrule\nchanged\n" {
+		t.Fatalf("unexpected output %q", report)
+	}
+}
@@ -0,0 +1,44 @@
+package container_run
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+
+	"github.com/seqrateam/seqra/internal/globals"
+)
+
+// applyResources sets the configured resource limits on the host config
+func applyResources(hostConfig *container.HostConfig) error {
+	resources := globals.Config.Resources
+	if resources.Memory != "" {
+		memory, err := units.RAMInBytes(resources.Memory)
+		if err != nil {
+			return fmt.Errorf("invalid memory limit %q: %w", resources.Memory, err)
+		}
+		hostConfig.Memory = memory
+		// Swapping makes the analysis crawl instead of failing, so the limit covers swap too
+		hostConfig.MemorySwap = memory
+	}
+	if resources.CPUs < 0 {
+		return fmt.Errorf("invalid cpus limit: %v", resources.CPUs)
+	}
+	if resources.CPUs > 0 {
+		hostConfig.NanoCPUs = int64(resources.CPUs * 1e9)
+	}
+	if resources.PidsLimit != 0 {
+		pidsLimit := resources.PidsLimit
+		hostConfig.PidsLimit = &pidsLimit
+	}
+	return nil
+}
+
+// oomMessage explains how to give the container more memory
+func oomMessage(taskName string) string {
+	if globals.Config.Resources.Memory != "" {
+		return fmt.Sprintf("%s container ran out of memory (limit %s). Increase --memory, or lower analyzer.heap and autobuilder.heap in the config so that the JVM fails with a clear error instead",
+			taskName, globals.Config.Resources.Memory)
+	}
+	return fmt.Sprintf("%s container was killed because the Docker host ran out of memory. Give Docker more memory, or set --memory and analyzer.heap to bound the analysis", taskName)
+}
@@ -2,8 +2,6 @@ package container_run
 
 import (
 	"archive/tar"
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -20,12 +18,13 @@ import (
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/client"
-	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/moby/go-archive"
 	"github.com/moby/sys/user"
 	"github.com/sirupsen/logrus"
 
+	"github.com/seqrateam/seqra/internal/failure"
 	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/phase"
 	"github.com/seqrateam/seqra/internal/utils"
 	"github.com/seqrateam/seqra/internal/utils/log"
 )
@@ -34,7 +33,34 @@ import (
 // https://docs.github.com/en/packages/working-with-a-github-packages-registry/working-with-the-container-registry#authenticating-with-a-personal-access-token-classic
 const ghcrUsername = "USERNAME"
 
-func RunGhcrContainer(taskName, imageLink string, flags []string, envCont []string, hostConfig *container.HostConfig, copyToContainer map[string]string, copyFromContainer map[string]string) {
+// Outcome tells how a container run with partial outputs ended
+type Outcome struct {
+	ExitCode int
+	// Stopped is set when the container ran out of the time of its task and was killed
+	Stopped bool
+}
+
+// Failed tells whether the container didn't finish successfully
+func (outcome Outcome) Failed() bool {
+	return outcome.ExitCode != 0 || outcome.Stopped
+}
+
+// RunGhcrContainer runs the image to completion within the time of the task. copyToContainer maps host paths
+// to container paths, copyFromContainer maps container paths to host paths, excludeFromCopy maps host paths
+// of copyToContainer to archive.TarOptions.ExcludePatterns used for them.
+func RunGhcrContainer(task *phase.Phase, imageLink string, flags []string, envCont []string, hostConfig *container.HostConfig, copyToContainer map[string]string, copyFromContainer map[string]string, excludeFromCopy map[string][]string) {
+	runContainer(task, imageLink, flags, envCont, hostConfig, copyToContainer, copyFromContainer, excludeFromCopy, false)
+}
+
+// RunGhcrContainerPartial is RunGhcrContainer for tasks whose outputs are useful even when the container
+// exits with an error or runs out of time. The outputs it managed to produce are copied in this case too.
+func RunGhcrContainerPartial(task *phase.Phase, imageLink string, flags []string, envCont []string, hostConfig *container.HostConfig, copyToContainer map[string]string, copyFromContainer map[string]string, excludeFromCopy map[string][]string) Outcome {
+	return runContainer(task, imageLink, flags, envCont, hostConfig, copyToContainer, copyFromContainer, excludeFromCopy, true)
+}
+
+func runContainer(task *phase.Phase, imageLink string, flags []string, envCont []string, hostConfig *container.HostConfig, copyToContainer map[string]string, copyFromContainer map[string]string, excludeFromCopy map[string][]string, partial bool) Outcome {
+	var outcome Outcome
+	taskName := task.Name()
 	logrus.Info("")
 	logrus.Infof("=== %s ===", taskName)
 
@@ -50,7 +76,7 @@ func RunGhcrContainer(taskName, imageLink string, flags []string, envCont []stri
 	}
 	logrus.Debugf("Image: %v", imageLink)
 	logrus.Debugf("Flags: %v", flags)
-	logrus.Debugf("Env: %v", envCont)
+	logrus.Debugf("Env: %v", utils.RedactEnv(envCont))
 
 	for _, copyTo := range copyFromContainer {
 		if _, err := os.Stat(copyTo); err == nil {
@@ -58,7 +84,6 @@ func RunGhcrContainer(taskName, imageLink string, flags []string, envCont []stri
 		}
 	}
 
-	ctx := context.Background()
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		logrus.Fatalf("Unexpected error occurred while trying to create docker client: %s", err)
@@ -67,77 +92,37 @@ func RunGhcrContainer(taskName, imageLink string, flags []string, envCont []stri
 		err = errors.Join(err, cli.Close())
 	}()
 
-	var options = image.PullOptions{}
-
-	if strings.HasPrefix(imageLink, globals.GithubDockerHost) {
-		var password = globals.Config.Github.Token
+	config.Image = ensureImage(cli, imageLink)
+	ctx := task.Context()
 
-		if password == "" {
-			cfg, err := cliconfig.Load("")
-			if err != nil {
-				logrus.Fatalf("Unexpected error occurred while trying to load Docker config: %s", err)
-			}
-
-			a, _ := cfg.GetAuthConfig(globals.GithubDockerHost)
-			password = a.Password
+	var boundOutputs []boundOutput
+	switch globals.Config.Mount.Mode {
+	case globals.MountCopy:
+	case globals.MountBind:
+		if !isLocalDaemon(cli.DaemonHost()) {
+			logrus.Warnf("Docker daemon %s isn't local, files are copied instead of bind-mounted", cli.DaemonHost())
+			break
 		}
-
-		if password != "" {
-			authConfig := registry.AuthConfig{
-				Username: ghcrUsername,
-				Password: password,
-			}
-			encodedJSON, err := json.Marshal(authConfig)
-			if err != nil {
-				logrus.Fatalf("Error while encoding authConfig: %s", err)
-			}
-
-			authStr := base64.URLEncoding.EncodeToString(encodedJSON)
-
-			options = image.PullOptions{
-				RegistryAuth: authStr,
-			}
+		copyToContainer, boundOutputs, err = planBindMounts(hostConfig, copyToContainer, copyFromContainer)
+		if err != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to prepare bind mounts: %s", err)
 		}
-	}
-
-	reader, imagePullErr := cli.ImagePull(ctx, imageLink, options)
-	if imagePullErr == nil {
-		defer func() {
-			err = errors.Join(err, reader.Close())
-		}()
-
-		logrus.Debugf("Pulling docker image: %s", imageLink)
-		// cli.ImagePull is asynchronous.
-		// The reader needs to be read completely for the pull operation to complete.
-		if globals.Config.Quiet {
-			// If stdout is not required, consider using io.Discard instead of os.Stdout.
-			_, _ = io.Copy(io.Discard, reader)
-		} else {
-			log.DisplayInteractiveProgress(reader)
+		copyFromContainer = nil
+		for _, output := range boundOutputs {
+			untrack := failure.Track(taskName+" output directory", output.hostDir, func() error {
+				return os.RemoveAll(output.hostDir)
+			})
+			defer untrack()
 		}
+		defer removeBoundOutputs(boundOutputs)
+	default:
+		logrus.Fatalf("mount-mode must be one of \"copy\", \"bind\"")
 	}
+	addCopyVolumes(hostConfig, copyToContainer, copyFromContainer)
+	logrus.Debugf("Mounts: %v", hostConfig.Mounts)
 
-	imageInspect, err := cli.ImageInspect(ctx, imageLink)
-	if err != nil {
-		if imagePullErr != nil {
-			logrus.Fatalf("Unexpected error occurred while trying to use image %s: %s", imageLink, imagePullErr)
-		} else {
-			logrus.Fatalf("Unexpected error occurred while trying to use image %s: %s", imageLink, err)
-		}
-	} else {
-		logrus.Debugf("Docker image: %s", imageLink)
-		logrus.Debugf("Image os: %s", imageInspect.Os)
-		logrus.Debugf("Image arch: %s", imageInspect.Architecture)
-		if len(imageInspect.RepoTags) == 1 {
-			logrus.Debugf("Docker tag: %s", imageInspect.RepoTags[0])
-		} else if len(imageInspect.RepoTags) > 1 {
-			logrus.Debugf("Docker tags:\n\t%s", strings.Join(imageInspect.RepoTags, "\n\t"))
-		}
-		if len(imageInspect.RepoDigests) == 1 {
-			logrus.Debugf("Docker digest: %s", imageInspect.RepoDigests[0])
-		} else if len(imageInspect.RepoDigests) > 1 {
-			logrus.Debugf("Docker digests:\n\t%s", strings.Join(imageInspect.RepoDigests, "\n\t"))
-		}
+	if err := applyResources(hostConfig); err != nil {
+		logrus.Fatal(err)
 	}
 
 	resp, err := cli.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
@@ -146,13 +131,17 @@ func RunGhcrContainer(taskName, imageLink string, flags []string, envCont []stri
 	}
 
 	logrus.Debugf("Container created ID: %s", resp.ID)
+	untrackContainer := failure.TrackContainer(taskName+" container", resp.ID, func() error {
+		return cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true, RemoveVolumes: true})
+	})
 
 	logrus.Infof("Start processing: %s", taskName)
 
 	for copyFrom, copyTo := range copyToContainer {
 		logrus.Debugf("Copy \"%v\" to container \"%v\"", copyFrom, copyTo)
-		err = CopyToContainer(cli, ctx, resp.ID, copyFrom, copyTo)
+		err = CopyToContainer(cli, ctx, resp.ID, copyFrom, copyTo, copyExtractDir(hostConfig, copyTo), excludeFromCopy[copyFrom])
 		if err != nil {
+			fatalIfExpired(task)
 			logrus.Errorf("Unexpected error occurred while trying to copy files to container: from %s to %s", copyFrom, copyTo)
 			logrus.Fatal(err)
 		}
@@ -160,20 +149,39 @@ func RunGhcrContainer(taskName, imageLink string, flags []string, envCont []stri
 	logrus.Debugf("Files copied to container: %v", len(copyToContainer))
 
 	if err := cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		fatalIfExpired(task)
 		logrus.Fatalf("Unexpected error occurred while trying to start container: %s", err)
 	}
 
 	defer func() {
-		_ = cli.ContainerKill(ctx, resp.ID, "SIGKILL")
+		_ = cli.ContainerKill(context.Background(), resp.ID, "SIGKILL")
 	}()
 
+	progress := log.NewProgressLine(taskName, globals.Config.Quiet)
+	logsDone := followLogs(ctx, cli, resp.ID, taskName, progress)
+
 	statusCh, errCh := cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
 	select {
 	case err := <-errCh:
+		progress.Stop()
 		if err != nil {
-			logrus.Fatalf("Unexpected error occurred while running container: %s", err)
+			if !task.Expired() {
+				logrus.Fatalf("Unexpected error occurred while running container: %s", err)
+			}
+			// Stop the container before its outputs are collected or the failure handler decides whether to keep it
+			_ = cli.ContainerKill(context.Background(), resp.ID, "SIGKILL")
+			if !partial {
+				logrus.Fatalf("%s, check the full logs: %s", task.Error(), globals.LogPath)
+			}
+			logrus.Warn(task.Error())
+			outcome.Stopped = true
+			// The context of the task is over, outputs are copied without a time limit
+			ctx = context.Background()
 		}
 	case statusBody := <-statusCh:
+		<-logsDone
+		progress.Stop()
+
 		inspect, err := cli.ContainerInspect(ctx, resp.ID)
 		if err != nil {
 			logrus.Fatalf("Unexpected error occurred while inspect container, after run: %s", err)
@@ -194,45 +202,27 @@ func RunGhcrContainer(taskName, imageLink string, flags []string, envCont []stri
 		logrus.Debugf("End processing")
 		logrus.Infof("Processing time: %vs", duration.Seconds())
 
-		// Get container logs and log them line by line at debug level
-		out, err := cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{
-			ShowStdout: true,
-			ShowStderr: true,
-			Details:    false,
-		})
-		defer func() {
-			err = out.Close()
-		}()
-		if err != nil {
-			logrus.Debugf("Failed to get container logs: %v", err)
-			return
-		} else {
-			var sourceBuffer bytes.Buffer
-			_, err := stdcopy.StdCopy(&sourceBuffer, &sourceBuffer, out)
-			if err != nil {
-				logrus.Fatalf("Unexpected error occurred while trying get logs from container: %s", err)
-			}
-			scanner := bufio.NewScanner(&sourceBuffer)
-
-			var allLogs string
-			for scanner.Scan() {
-				allLogs += scanner.Text() + "\n"
-			}
-			if err := scanner.Err(); err != nil {
-				logrus.Debugf("Error reading container logs: %v", err)
-			}
-			logrus.Debugf("Container log:\n%s", allLogs)
+		if inspect.State.OOMKilled {
+			logrus.Fatal(oomMessage(taskName))
 		}
-
 		if statusBody.StatusCode != 0 {
-			logrus.Fatalf("Container exited with non-zero exit code: %d", statusBody.StatusCode)
+			if !partial {
+				logrus.Fatalf("Container exited with non-zero exit code: %d", statusBody.StatusCode)
+			}
+			logrus.Warnf("Container exited with non-zero exit code: %d", statusBody.StatusCode)
+			outcome.ExitCode = int(statusBody.StatusCode)
 		}
 	}
 
 	for copyFrom, copyTo := range copyFromContainer {
 		logrus.Debugf("Copy \"%v\" from container to \"%v\"", copyFrom, copyTo)
 		err = CopyFileFromContainer(cli, ctx, resp.ID, copyFrom, copyTo)
+		if err != nil && outcome.Failed() {
+			logrus.Debugf("Failed container didn't produce %s: %s", copyFrom, err)
+			continue
+		}
 		if err != nil {
+			fatalIfExpired(task)
 			logrus.Error(err)
 			if taskName == "Compile" {
 				logrus.Error("Try compile with flag --native")
@@ -242,16 +232,202 @@ func RunGhcrContainer(taskName, imageLink string, flags []string, envCont []stri
 	}
 	logrus.Debugf("Files copied from container: %v", len(copyFromContainer))
 
-	err = cli.ContainerStop(ctx, resp.ID, container.StopOptions{})
+	if err := collectBoundOutputs(boundOutputs, outcome.Failed()); err != nil {
+		logrus.Error(err)
+		logrus.Fatalf("There was a problem during the %s step, check the full logs: %s", taskName, globals.LogPath)
+	}
+
+	untrackContainer()
+	err = cli.ContainerStop(context.Background(), resp.ID, container.StopOptions{})
 	if err != nil {
 		logrus.Fatalf("Unexpected error occurred while stopping container: %s", err)
 	}
 
 	// TODO add some logs if container exists due to some error
-	err = cli.ContainerRemove(ctx, resp.ID, container.RemoveOptions{})
+	err = cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{RemoveVolumes: true})
 	if err != nil {
 		logrus.Fatalf("Unexpected error occurred while removing container: %s", err)
 	}
+	return outcome
+}
+
+// fatalIfExpired reports a task which ran out of time instead of the error its canceled context caused
+func fatalIfExpired(task *phase.Phase) {
+	if task.Expired() {
+		logrus.Fatalf("%s, check the full logs: %s", task.Error(), globals.LogPath)
+	}
+}
+
+// PullGhcrImage makes the image available in the local Docker daemon according to the pull policy
+func PullGhcrImage(imageLink string) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to create docker client: %s", err)
+	}
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	ensureImage(cli, imageLink)
+}
+
+// ensureImage makes the image available locally within the pull timeout
+// and returns the reference the container should be created with
+func ensureImage(cli *client.Client, imageLink string) string {
+	pull := phase.Begin("Pull of "+imageLink, "pull.timeout", globals.Config.Pull.Timeout)
+	defer pull.End()
+	ctx := pull.Context()
+
+	policy := globals.Config.Pull.Policy
+	switch policy {
+	case globals.PullAlways, globals.PullMissing, globals.PullNever:
+	default:
+		logrus.Fatalf("pull must be one of \"%s\", \"%s\", \"%s\"", globals.PullAlways, globals.PullMissing, globals.PullNever)
+	}
+	if globals.Config.Offline {
+		policy = globals.PullNever
+	}
+
+	var imagePullErr error
+	if policy == globals.PullMissing {
+		if _, err := resolveImageID(ctx, cli, imageLink); err == nil {
+			logrus.Debugf("Docker image is already present, skip pull: %s", imageLink)
+			policy = globals.PullNever
+		}
+	}
+	if policy != globals.PullNever {
+		imagePullErr = pullImage(ctx, cli, imageLink)
+		fatalIfExpired(pull)
+	}
+
+	imageInspect, err := cli.ImageInspect(ctx, imageLink)
+	if err != nil {
+		if imported, ok := importedImageFor(imageLink); ok {
+			if _, idErr := cli.ImageInspect(ctx, imported.ID); idErr == nil {
+				verifyImageDigest(imageLink, imported.RepoDigests)
+				logrus.Debugf("Use imported image %s for %s", imported.ID, imageLink)
+				return imported.ID
+			}
+		}
+		if globals.Config.Offline {
+			logrus.Fatalf("Image %s isn't available locally and network access is disabled by --offline, import it with \"seqra bundle import\"", imageLink)
+		}
+		if imagePullErr != nil {
+			logrus.Fatalf("Unexpected error occurred while trying to use image %s: %s", imageLink, imagePullErr)
+		} else {
+			logrus.Fatalf("Unexpected error occurred while trying to use image %s: %s", imageLink, err)
+		}
+	} else {
+		logrus.Debugf("Docker image: %s", imageLink)
+		logrus.Debugf("Image os: %s", imageInspect.Os)
+		logrus.Debugf("Image arch: %s", imageInspect.Architecture)
+		if len(imageInspect.RepoTags) == 1 {
+			logrus.Debugf("Docker tag: %s", imageInspect.RepoTags[0])
+		} else if len(imageInspect.RepoTags) > 1 {
+			logrus.Debugf("Docker tags:\n\t%s", strings.Join(imageInspect.RepoTags, "\n\t"))
+		}
+		if len(imageInspect.RepoDigests) == 1 {
+			logrus.Debugf("Docker digest: %s", imageInspect.RepoDigests[0])
+		} else if len(imageInspect.RepoDigests) > 1 {
+			logrus.Debugf("Docker digests:\n\t%s", strings.Join(imageInspect.RepoDigests, "\n\t"))
+		}
+	}
+
+	verifyImageDigest(imageLink, imageInspect.RepoDigests)
+	return imageLink
+}
+
+// verifyImageDigest checks that an image referenced by digest really has this digest
+// and, when digest pinning is enforced, that the reference is pinned at all
+func verifyImageDigest(imageLink string, repoDigests []string) {
+	expectedDigest := utils.GetImageDigest(imageLink)
+	if expectedDigest == "" {
+		if globals.Config.Pull.RequireDigest {
+			logrus.Fatalf("Image %s isn't pinned by digest, seqra doesn't ship image digests, set analyzer.digest and autobuilder.digest in the config", imageLink)
+		}
+		logrus.Debugf("Docker image %s isn't pinned by digest", imageLink)
+		return
+	}
+
+	if hasDigest(imageLink, repoDigests) {
+		logrus.Debugf("Docker image digest verified: %s", expectedDigest)
+		return
+	}
+	logrus.Fatalf("Docker image digest mismatch for %s: local image has digests %v", imageLink, repoDigests)
+}
+
+func pullImage(ctx context.Context, cli *client.Client, imageLink string) (err error) {
+	var options = image.PullOptions{}
+
+	authConfig := registryAuth(utils.GetImageRegistryHost(imageLink))
+	if authConfig != nil {
+		encodedJSON, err := json.Marshal(authConfig)
+		if err != nil {
+			logrus.Fatalf("Error while encoding authConfig: %s", err)
+		}
+
+		authStr := base64.URLEncoding.EncodeToString(encodedJSON)
+
+		options = image.PullOptions{
+			RegistryAuth: authStr,
+		}
+	}
+
+	reader, err := cli.ImagePull(ctx, imageLink, options)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, reader.Close())
+	}()
+
+	logrus.Debugf("Pulling docker image: %s", imageLink)
+	// cli.ImagePull is asynchronous.
+	// The reader needs to be read completely for the pull operation to complete.
+	if globals.Config.Quiet {
+		// If stdout is not required, consider using io.Discard instead of os.Stdout.
+		_, _ = io.Copy(io.Discard, reader)
+	} else {
+		log.DisplayInteractiveProgress(reader)
+	}
+	return nil
+}
+
+// registryAuth returns credentials for the registry host, nil for anonymous pull.
+// ghcr.io accepts a token with any username, other registries use credentials from Docker config or its credential helpers.
+func registryAuth(host string) *registry.AuthConfig {
+	if host == globals.GithubDockerHost && globals.Config.Github.Token != "" {
+		return &registry.AuthConfig{Username: ghcrUsername, Password: globals.Config.Github.Token}
+	}
+
+	cfg, err := cliconfig.Load("")
+	if err != nil {
+		logrus.Fatalf("Unexpected error occurred while trying to load Docker config: %s", err)
+	}
+
+	a, err := cfg.GetAuthConfig(host)
+	if err != nil {
+		logrus.Debugf("Can't get Docker credentials for %s: %s", host, err)
+		return nil
+	}
+
+	if host == globals.GithubDockerHost {
+		if a.Password == "" {
+			return nil
+		}
+		return &registry.AuthConfig{Username: ghcrUsername, Password: a.Password}
+	}
+
+	if a.Username == "" && a.Password == "" && a.IdentityToken == "" && a.RegistryToken == "" {
+		return nil
+	}
+	return &registry.AuthConfig{
+		Username:      a.Username,
+		Password:      a.Password,
+		ServerAddress: host,
+		IdentityToken: a.IdentityToken,
+		RegistryToken: a.RegistryToken,
+	}
 }
 
 func CopyFileFromContainer(cli *client.Client, ctx context.Context, containerID, containerPath, hostPath string) error {
@@ -281,7 +457,8 @@ func CopyFileFromContainer(cli *client.Client, ctx context.Context, containerID,
 	return nil
 }
 
-func CopyToContainer(cli *client.Client, ctx context.Context, containerID string, localDir string, containerDestPath string) error {
+// CopyToContainer copies localDir to containerDestPath, extracting it in extractDir which has to hold containerDestPath
+func CopyToContainer(cli *client.Client, ctx context.Context, containerID string, localDir string, containerDestPath string, extractDir string, excludePatterns []string) error {
 	_, err := os.Stat(localDir)
 	if err != nil {
 		return fmt.Errorf("cannot stat local path: %w", err)
@@ -293,9 +470,9 @@ func CopyToContainer(cli *client.Client, ctx context.Context, containerID string
 	// Setup minimal identity map (no remapping)
 	idMap := user.IdentityMapping{}
 
-	// Rebase: this tells Docker to unpack your files into /app/data instead of /local
+	// Rebase: this tells Docker to unpack your files into containerDestPath relative to extractDir instead of /local
 	rebase := map[string]string{
-		baseName: containerDestPath,
+		baseName: copyRelPath(extractDir, containerDestPath),
 	}
 
 	tarOpts := &archive.TarOptions{
@@ -303,6 +480,7 @@ func CopyToContainer(cli *client.Client, ctx context.Context, containerID string
 		RebaseNames:      rebase,
 		IDMap:            idMap,
 		IncludeSourceDir: true,
+		ExcludePatterns:  excludePatterns,
 	}
 
 	tarStream, err := archive.TarWithOptions(parentDir, tarOpts)
@@ -316,8 +494,8 @@ func CopyToContainer(cli *client.Client, ctx context.Context, containerID string
 		}
 	}()
 
-	// Copy to root, since tar contains full path structure
-	err = cli.CopyToContainer(ctx, containerID, "/", tarStream, container.CopyToContainerOptions{
+	// The tar contains the path structure below extractDir
+	err = cli.CopyToContainer(ctx, containerID, extractDir, tarStream, container.CopyToContainerOptions{
 		AllowOverwriteDirWithFile: true,
 	})
 	if err != nil {
@@ -326,3 +504,12 @@ func CopyToContainer(cli *client.Client, ctx context.Context, containerID string
 
 	return nil
 }
+
+// copyRelPath returns containerPath relative to extractDir, "." for extractDir itself
+func copyRelPath(extractDir, containerPath string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(containerPath, strings.TrimSuffix(extractDir, "/")), "/")
+	if rel == "" {
+		return "."
+	}
+	return rel
+}
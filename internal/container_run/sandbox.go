@@ -0,0 +1,32 @@
+package container_run
+
+import (
+	"github.com/docker/docker/api/types/container"
+
+	"github.com/seqrateam/seqra/internal/globals"
+)
+
+// sandboxCapabilities are kept when capabilities are dropped: the entrypoint needs them
+// to hand the files to CONTAINER_UID and to switch to that user
+var sandboxCapabilities = []string{"CHOWN", "SETUID", "SETGID"}
+
+// ApplySandbox isolates a container which needs neither network nor writes outside its data directories
+func ApplySandbox(hostConfig *container.HostConfig, sandbox globals.Sandbox) {
+	if !sandbox.AllowNetwork {
+		hostConfig.NetworkMode = "none"
+	}
+	if !sandbox.WritableRootfs {
+		hostConfig.ReadonlyRootfs = true
+		if hostConfig.Tmpfs == nil {
+			hostConfig.Tmpfs = make(map[string]string)
+		}
+		hostConfig.Tmpfs["/tmp"] = ""
+	}
+	if !sandbox.KeepCapabilities {
+		hostConfig.CapDrop = []string{"ALL"}
+		hostConfig.CapAdd = sandboxCapabilities
+	}
+	if !sandbox.AllowNewPrivileges {
+		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges:true")
+	}
+}
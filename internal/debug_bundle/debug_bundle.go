@@ -0,0 +1,202 @@
+package debug_bundle
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+// redactedKeys are parts of config keys whose values are never put into a bundle
+var redactedKeys = []string{"token", "password", "secret", "username"}
+
+const redacted = utils.Redacted
+
+var (
+	secretKeyPattern = "[\\w.-]*(?:" + strings.Join(redactedKeys, "|") + ")[\\w.-]*"
+	// urlUserinfoPattern matches credentials of URLs, also of proxies given without a scheme
+	urlUserinfoPattern = regexp.MustCompile(`((?:[a-zA-Z][a-zA-Z0-9+.-]*://|(?i:_proxy)=))[^\s/@"']+@`)
+	// secretValuePattern matches values of secret keys, like token=... or "password": "..."
+	secretValuePattern = regexp.MustCompile(`(?i)(` + secretKeyPattern + `"?\s*[:=]\s*"?)[^\s",]+`)
+	// secretFlagPattern matches values of secret flags separated by a space, like --github-token ...
+	secretFlagPattern = regexp.MustCompile(`(?i)(--` + secretKeyPattern + `\s+)[^\s-]\S*`)
+)
+
+// Writer builds a zip archive for attaching to issues
+type Writer struct {
+	out *os.File
+	zw  *zip.Writer
+}
+
+func Create(path string) (*Writer, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{out: out, zw: zip.NewWriter(out)}, nil
+}
+
+// AddFile copies a file into the bundle under name
+func (w *Writer) AddFile(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	entry, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
+
+// AddLog copies a log into the bundle under name with secrets redacted line by line
+func (w *Writer) AddLog(name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return w.AddEntry(name, func(out io.Writer) error {
+		return copyRedacted(out, f)
+	})
+}
+
+// AddLogEntry is AddLog for a log written by write, e.g. logs of a container
+func (w *Writer) AddLogEntry(name string, write func(io.Writer) error) error {
+	return w.AddEntry(name, func(out io.Writer) error {
+		reader, writer := io.Pipe()
+		go func() {
+			_ = writer.CloseWithError(write(writer))
+		}()
+		err := copyRedacted(out, reader)
+		_ = reader.CloseWithError(err)
+		return err
+	})
+}
+
+func copyRedacted(out io.Writer, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if _, err := io.WriteString(out, RedactText(scanner.Text())+"\n"); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// AddEntry creates an entry written by write
+func (w *Writer) AddEntry(name string, write func(io.Writer) error) error {
+	entry, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return write(entry)
+}
+
+// AddConfig adds the settings with secrets redacted
+func (w *Writer) AddConfig(name string, settings map[string]interface{}) error {
+	return w.AddEntry(name, func(out io.Writer) error {
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(Redact(settings))
+	})
+}
+
+// AddText adds lines as a text entry
+func (w *Writer) AddText(name string, lines []string) error {
+	return w.AddEntry(name, func(out io.Writer) error {
+		_, err := io.WriteString(out, strings.Join(lines, "\n")+"\n")
+		return err
+	})
+}
+
+func (w *Writer) Close() error {
+	if err := w.zw.Close(); err != nil {
+		_ = w.out.Close()
+		return err
+	}
+	return w.out.Close()
+}
+
+// Redact returns a copy of settings where values of secret keys are replaced
+func Redact(settings map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			result[key] = Redact(typed)
+		case string:
+			if isSecretKey(key) && typed != "" {
+				result[key] = redacted
+			} else {
+				result[key] = utils.RedactURL(typed)
+			}
+		default:
+			if isSecretKey(key) && value != nil {
+				result[key] = redacted
+			} else {
+				result[key] = value
+			}
+		}
+	}
+	return result
+}
+
+// RedactArgs returns a copy of command line arguments with values of secret flags replaced
+func RedactArgs(args []string) []string {
+	result := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		switch {
+		case redactNext:
+			result[i] = redacted
+			redactNext = false
+		case strings.HasPrefix(arg, "-") && isSecretKey(arg):
+			if name, _, ok := strings.Cut(arg, "="); ok {
+				result[i] = name + "=" + redacted
+			} else {
+				result[i] = arg
+				redactNext = true
+			}
+		default:
+			result[i] = utils.RedactURL(arg)
+		}
+	}
+	return result
+}
+
+// RedactText hides URL credentials and values of secret keys and flags in free text, e.g. a log line
+func RedactText(text string) string {
+	text = urlUserinfoPattern.ReplaceAllString(text, "${1}"+redacted+"@")
+	text = secretValuePattern.ReplaceAllString(text, "${1}"+redacted)
+	return secretFlagPattern.ReplaceAllString(text, "${1}"+redacted)
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range redactedKeys {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// EntryName returns a bundle entry name for a file put into the directory dir of the bundle
+func EntryName(dir, path string) string {
+	return dir + "/" + filepath.Base(path)
+}
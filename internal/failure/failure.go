@@ -0,0 +1,162 @@
+package failure
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+const recordFileName = "last_failure.json"
+
+// Kept is an artifact left in place after a failure
+type Kept struct {
+	Description string `json:"description"`
+	ID          string `json:"id"`
+	Container   bool   `json:"container,omitempty"`
+}
+
+// Record describes the last failed run, it is read by `seqra debug bundle`
+type Record struct {
+	Time    time.Time `json:"time"`
+	Command []string  `json:"command"`
+	LogPath string    `json:"log_path"`
+	Kept    []Kept    `json:"kept,omitempty"`
+}
+
+type tracked struct {
+	Kept
+	remove func() error
+	// release is run even with --keep-on-failure, it isn't an artifact but e.g. a lease
+	release bool
+}
+
+var (
+	mu      sync.Mutex
+	nextKey int
+	active  = make(map[int]tracked)
+	order   []int
+)
+
+func init() {
+	logrus.RegisterExitHandler(handleFailure)
+}
+
+// Track registers an artifact of the current run, e.g. a container, which is removed when the run fails
+// with logrus.Fatal, or kept and reported with --keep-on-failure. The returned function stops tracking
+// once the artifact is cleaned up normally.
+func Track(description, id string, remove func() error) (untrack func()) {
+	return track(Kept{Description: description, ID: id}, remove, false)
+}
+
+// TrackContainer is Track for a Docker container, whose logs are collected by `seqra debug bundle`
+func TrackContainer(description, id string, remove func() error) (untrack func()) {
+	return track(Kept{Description: description, ID: id, Container: true}, remove, false)
+}
+
+// TrackRelease registers a release, e.g. of a lease, which is run when the run fails with logrus.Fatal
+// also with --keep-on-failure. The returned function stops tracking once it is released normally.
+func TrackRelease(description, id string, release func()) (untrack func()) {
+	return track(Kept{Description: description, ID: id}, func() error {
+		release()
+		return nil
+	}, true)
+}
+
+func track(kept Kept, remove func() error, release bool) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	key := nextKey
+	nextKey++
+	active[key] = tracked{Kept: kept, remove: remove, release: release}
+	order = append(order, key)
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(active, key)
+	}
+}
+
+func handleFailure() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	record := Record{Time: time.Now(), Command: os.Args, LogPath: globals.LogPath}
+	// Newest first, containers are removed before the directories they use
+	for i := len(order) - 1; i >= 0; i-- {
+		artifact, ok := active[order[i]]
+		if !ok {
+			continue
+		}
+		if globals.Config.KeepOnFailure && !artifact.release {
+			record.Kept = append(record.Kept, artifact.Kept)
+			logrus.Errorf("Kept for debugging: %s %s", artifact.Description, artifact.ID)
+			continue
+		}
+		if err := artifact.remove(); err != nil {
+			logrus.Debugf("Failed to remove %s %s: %s", artifact.Description, artifact.ID, err)
+		}
+	}
+	active = make(map[int]tracked)
+
+	if err := writeRecord(record); err != nil {
+		logrus.Debugf("Failed to record the failure: %s", err)
+	}
+	for _, kept := range record.Kept {
+		if kept.Container {
+			logrus.Error("Remove kept containers with `docker rm -f <id>` when you are done")
+			break
+		}
+	}
+	if globals.LogPath != "" {
+		logrus.Error("Run `seqra debug bundle` to collect logs for a bug report")
+	}
+}
+
+func recordPath() (string, error) {
+	seqraHomePath, err := utils.GetSeqraHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(seqraHomePath, recordFileName), nil
+}
+
+func writeRecord(record Record) error {
+	path, err := recordPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LastRecord returns the record of the last failed run, nil if no run failed
+func LastRecord() (*Record, error) {
+	path, err := recordPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
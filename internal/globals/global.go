@@ -8,24 +8,177 @@ const GithubDockerHost = "ghcr.io"
 
 const RepoOwner = "seqrateam"
 
-const AnalyzerDocker = GithubDockerHost + "/" + RepoOwner + "/seqra-jvm-sast/sast-analyzer"
+const AnalyzerRepoName = "seqra-jvm-sast"
+const AnalyzerDocker = GithubDockerHost + "/" + RepoOwner + "/" + AnalyzerRepoName + "/sast-analyzer"
 const AnalyzerBindVersion = "2025.09.02.80c3c9b"
 
+// AnalyzerBindDigest is the expected digest of the AnalyzerBindVersion image, empty while it isn't pinned.
+// Until it is, --require-image-digest needs the digest in the config.
+const AnalyzerBindDigest = ""
+
+// AnalyzerAssetName is the default name of the analyzer jar among the release assets, analyzer.asset overrides it
+const AnalyzerAssetName = "seqra-project-analyzer.jar"
+
+// AnalyzerJavaVersion is the minimal Java feature release able to run the analyzer jar natively
+const AnalyzerJavaVersion = 17
+
 const AutobuilderRepoName = "seqra-jvm-autobuilder"
 const AutobuilderDocker = GithubDockerHost + "/" + RepoOwner + "/" + AutobuilderRepoName + "/sast-autobuilder"
 const AutobuilderBindVersion = "2025.09.01.8dde52f"
+
+// AutobuilderBindDigest is the expected digest of the AutobuilderBindVersion image, empty while it isn't pinned.
+// Until it is, --require-image-digest needs the digest in the config.
+const AutobuilderBindDigest = ""
 const AutobuilderAssetName = "seqra-project-auto-builder.jar"
 
 const RulesRepoName = "seqra-rules"
 const RulesBindVersion = "v1.0.1"
 
+// ChecksumsAssetName is the release asset with SHA-256 digests of other assets in sha256sum format.
+// An ed25519 signature of it may be published as ChecksumsAssetName + ".sig".
+const ChecksumsAssetName = "checksums.txt"
+
+// ArtifactDigests pins expected SHA-256 digests of downloaded artifacts, keyed by ArtifactDigestKey.
+// Pinned digests take precedence over the release checksums asset. None of the bundled versions are pinned yet,
+// verify.digests pins them in the config.
+var ArtifactDigests = map[string]string{}
+
+func ArtifactDigestKey(repository, version, artifactName string) string {
+	return repository + "/" + version + "/" + artifactName
+}
+
+// AnalyzerAsset returns the name of the analyzer jar among the release assets
+func AnalyzerAsset() string {
+	if Config.Analyzer.Asset != "" {
+		return Config.Analyzer.Asset
+	}
+	return AnalyzerAssetName
+}
+
+// PinnedArtifactDigest returns the digest of an artifact pinned in the config or in ArtifactDigests
+func PinnedArtifactDigest(repository, version, artifactName string) (string, bool) {
+	key := ArtifactDigestKey(repository, version, artifactName)
+	for _, pinned := range Config.Verify.Digests {
+		if pinned.Artifact == key {
+			return pinned.SHA256, true
+		}
+	}
+	digest, ok := ArtifactDigests[key]
+	return digest, ok
+}
+
+const (
+	PullAlways  = "always"
+	PullMissing = "missing"
+	PullNever   = "never"
+)
+
+const (
+	MountCopy = "copy"
+	MountBind = "bind"
+)
+
+type Mount struct {
+	// Mode is copy (through the Docker API) or bind (bind mounts, local Docker daemons only)
+	Mode string `mapstructure:"mode"`
+}
+
 type Compile struct {
 	Type string `mapstructure:"type"`
+	// MavenSettings is a settings.xml used by Maven builds in the autobuilder container
+	MavenSettings string `mapstructure:"maven_settings"`
+	// GradleInitScripts are put into init.d of the Gradle user home in the autobuilder container
+	GradleInitScripts []string        `mapstructure:"gradle_init_scripts"`
+	DependencyCache   DependencyCache `mapstructure:"dependency_cache"`
+	// Network is the Docker network of the autobuilder container, e.g. one where only a proxy is reachable
+	Network string `mapstructure:"network"`
+	// JavaHome is the JDK of native compiles, by default one matching the project is chosen from installed JDKs
+	JavaHome string `mapstructure:"java_home"`
+	// BuildSystem is maven, gradle, ant or bazel, empty to detect it
+	BuildSystem string `mapstructure:"build_system"`
+	// Goals are Maven goals or Gradle tasks run instead of the default ones
+	Goals            []string `mapstructure:"goals"`
+	MavenProfiles    []string `mapstructure:"maven_profiles"`
+	GradleProperties []string `mapstructure:"gradle_properties"`
+	// Subprojects limits the build to these Maven modules or Gradle projects
+	Subprojects []string `mapstructure:"subprojects"`
+	// BuildCommand replaces the build of the autobuilder, it's run in the project root and
+	// has to produce ClasspathFile, a path relative to the project root
+	BuildCommand  string `mapstructure:"build_command"`
+	ClasspathFile string `mapstructure:"classpath_file"`
+	// Timeout bounds the autobuilder run, zero means no limit
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// DependencyCache keeps Maven and Gradle dependencies of dockerized compiles between runs
+type DependencyCache struct {
+	// Mode is none, volume (named Docker volumes) or bind (host Maven and Gradle directories)
+	Mode string `mapstructure:"mode"`
+	// Volume is the prefix of volume names in volume mode
+	Volume string `mapstructure:"volume"`
+	// MavenDir and GradleDir override ~/.m2 and ~/.gradle in bind mode
+	MavenDir  string `mapstructure:"maven_dir"`
+	GradleDir string `mapstructure:"gradle_dir"`
+	// ReadOnly lets builds use cached dependencies without adding new ones
+	ReadOnly bool `mapstructure:"read_only"`
 }
 
 type Scan struct {
+	// Type is docker (the analyzer image) or native (the analyzer jar on a local JDK)
+	Type    string        `mapstructure:"type"`
 	Timeout time.Duration `mapstructure:"timeout"`
 	Ruleset string        `mapstructure:"ruleset"`
+	Sandbox Sandbox       `mapstructure:"sandbox"`
+	// Incremental reanalyzes only files changed since the previous scan of the project
+	Incremental bool `mapstructure:"incremental"`
+}
+
+// Sandbox holds opt-outs from the isolation of the analyzer container
+type Sandbox struct {
+	AllowNetwork       bool `mapstructure:"allow_network"`
+	WritableRootfs     bool `mapstructure:"writable_rootfs"`
+	KeepCapabilities   bool `mapstructure:"keep_capabilities"`
+	AllowNewPrivileges bool `mapstructure:"allow_new_privileges"`
+}
+
+type ModelCache struct {
+	Disabled bool   `mapstructure:"disabled"`
+	MaxSize  string `mapstructure:"max_size"`
+}
+
+type Pull struct {
+	Policy        string `mapstructure:"policy"`
+	RequireDigest bool   `mapstructure:"require_digest"`
+	// Timeout bounds a single image pull or artifact download, zero means no limit
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+type Registry struct {
+	// Mirror replaces GithubDockerHost/RepoOwner in image references, e.g. "registry.example.com/seqrateam"
+	Mirror string `mapstructure:"mirror"`
+}
+
+type Artifacts struct {
+	BaseURL  string `mapstructure:"base_url"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type Network struct {
+	// CABundle is a PEM file with CA certificates to trust, e.g. of a TLS-intercepting proxy
+	CABundle string `mapstructure:"ca_bundle"`
+}
+
+// ArtifactDigest pins the SHA-256 digest of an artifact named like ArtifactDigestKey
+type ArtifactDigest struct {
+	Artifact string `mapstructure:"artifact"`
+	SHA256   string `mapstructure:"sha256"`
+}
+
+type Verify struct {
+	RequireChecksum bool             `mapstructure:"require_checksum"`
+	PublicKey       string           `mapstructure:"public_key"`
+	Digests         []ArtifactDigest `mapstructure:"digests"`
 }
 
 type Log struct {
@@ -36,12 +189,29 @@ type Github struct {
 	Token string `mapstructure:"token"`
 }
 
+// Resources limit each analyzer and autobuilder container
+type Resources struct {
+	// Memory is a size like "8g", empty for no limit
+	Memory    string  `mapstructure:"memory"`
+	CPUs      float64 `mapstructure:"cpus"`
+	PidsLimit int64   `mapstructure:"pids_limit"`
+}
+
 type Analyzer struct {
 	Version string `mapstructure:"version"`
+	Digest  string `mapstructure:"digest"`
+	// Asset is the name of the analyzer jar among the release assets, used by native scans
+	Asset string `mapstructure:"asset"`
+	// Heap is the maximum JVM heap like "6g", JavaOptions are additional JVM options
+	Heap        string `mapstructure:"heap"`
+	JavaOptions string `mapstructure:"java_options"`
 }
 
 type Autobuilder struct {
-	Version string `mapstructure:"version"`
+	Version     string `mapstructure:"version"`
+	Digest      string `mapstructure:"digest"`
+	Heap        string `mapstructure:"heap"`
+	JavaOptions string `mapstructure:"java_options"`
 }
 
 type ConfigType struct {
@@ -51,7 +221,21 @@ type ConfigType struct {
 	Analyzer    Analyzer    `mapstructure:"analyzer"`
 	Autobuilder Autobuilder `mapstructure:"autobuilder"`
 	Compile     Compile     `mapstructure:"compile"`
-	Quiet       bool        `mapstructure:"quiet"`
+	Pull        Pull        `mapstructure:"pull"`
+	ModelCache  ModelCache  `mapstructure:"model_cache"`
+	Verify      Verify      `mapstructure:"verify"`
+	Registry    Registry    `mapstructure:"registry"`
+	Artifacts   Artifacts   `mapstructure:"artifacts"`
+	Network     Network     `mapstructure:"network"`
+	Mount       Mount       `mapstructure:"mount"`
+	Resources   Resources   `mapstructure:"resources"`
+	// Exclude lists gitignore patterns of project paths which are not sent to containers
+	Exclude       []string `mapstructure:"exclude"`
+	Quiet         bool     `mapstructure:"quiet"`
+	Offline       bool     `mapstructure:"offline"`
+	KeepOnFailure bool     `mapstructure:"keep_on_failure"`
+	// Deadline bounds the whole run, zero means no limit
+	Deadline time.Duration `mapstructure:"deadline"`
 }
 
 var Config ConfigType
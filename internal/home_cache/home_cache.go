@@ -0,0 +1,204 @@
+package home_cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/seqrateam/seqra/internal/model_cache"
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+type ArtifactType string
+
+const (
+	ArtifactAnalyzer    ArtifactType = "analyzer"
+	ArtifactAutobuilder ArtifactType = "autobuilder"
+	ArtifactRules       ArtifactType = "rules"
+	ArtifactModel       ArtifactType = "model"
+	ArtifactLog         ArtifactType = "log"
+	ArtifactTemp        ArtifactType = "temp"
+)
+
+// ArtifactTypes lists artifact types in the order they are reported
+var ArtifactTypes = []ArtifactType{ArtifactAnalyzer, ArtifactAutobuilder, ArtifactRules, ArtifactModel, ArtifactLog, ArtifactTemp}
+
+// Artifact is a single file or directory stored in seqra home
+type Artifact struct {
+	Type    ArtifactType
+	Version string
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+const (
+	analyzerPrefix    = "analyzer_"
+	autobuilderPrefix = "autobuilder_"
+	jarSuffix         = ".jar"
+	rulesPrefix       = "rules_"
+	tempSuffix        = ".temp"
+	// staleTempAge is the age after which a .temp file is left by an interrupted download, not written by a running one
+	staleTempAge = time.Hour
+)
+
+// List returns all artifacts stored in seqra home
+func List() ([]Artifact, error) {
+	seqraHomePath, err := utils.GetSeqraHome()
+	if err != nil {
+		return nil, err
+	}
+
+	dirEntries, err := os.ReadDir(seqraHomePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifacts []Artifact
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		artifact := Artifact{Path: filepath.Join(seqraHomePath, name)}
+
+		switch {
+		case strings.HasSuffix(name, tempSuffix):
+			artifact.Type = ArtifactTemp
+		case !dirEntry.IsDir() && strings.HasPrefix(name, analyzerPrefix) && strings.HasSuffix(name, jarSuffix):
+			artifact.Type = ArtifactAnalyzer
+			artifact.Version = strings.TrimSuffix(strings.TrimPrefix(name, analyzerPrefix), jarSuffix)
+		case !dirEntry.IsDir() && strings.HasPrefix(name, autobuilderPrefix) && strings.HasSuffix(name, jarSuffix):
+			artifact.Type = ArtifactAutobuilder
+			artifact.Version = strings.TrimSuffix(strings.TrimPrefix(name, autobuilderPrefix), jarSuffix)
+		case dirEntry.IsDir() && strings.HasPrefix(name, rulesPrefix):
+			artifact.Type = ArtifactRules
+			artifact.Version = strings.TrimPrefix(name, rulesPrefix)
+		default:
+			continue
+		}
+
+		if err := fillSize(&artifact); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, artifact)
+	}
+
+	models, err := model_cache.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, model := range models {
+		artifacts = append(artifacts, Artifact{
+			Type:    ArtifactModel,
+			Version: model.Key,
+			Path:    model.Path,
+			Size:    model.Size,
+			ModTime: model.LastUsed,
+		})
+	}
+
+	logs, err := listLogs(filepath.Join(seqraHomePath, "logs"))
+	if err != nil {
+		return nil, err
+	}
+	artifacts = append(artifacts, logs...)
+
+	return artifacts, nil
+}
+
+// listLogs returns log files ordered from the newest
+func listLogs(logDir string) ([]Artifact, error) {
+	dirEntries, err := os.ReadDir(logDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []Artifact
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || filepath.Ext(dirEntry.Name()) != ".log" {
+			continue
+		}
+		artifact := Artifact{
+			Type:    ArtifactLog,
+			Version: strings.TrimSuffix(dirEntry.Name(), ".log"),
+			Path:    filepath.Join(logDir, dirEntry.Name()),
+		}
+		if err := fillSize(&artifact); err != nil {
+			return nil, err
+		}
+		logs = append(logs, artifact)
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].ModTime.After(logs[j].ModTime)
+	})
+	return logs, nil
+}
+
+func fillSize(artifact *Artifact) error {
+	info, err := os.Stat(artifact.Path)
+	if err != nil {
+		return err
+	}
+	artifact.ModTime = info.ModTime()
+	if info.IsDir() {
+		artifact.Size, err = utils.DirSize(artifact.Path)
+		return err
+	}
+	artifact.Size = info.Size()
+	return nil
+}
+
+// Stale selects artifacts which are not used by the current versions:
+// jars and rules of other versions, leftovers of interrupted downloads older than an hour and logs beyond keepLogs newest ones.
+// Files listed in inUse are never selected.
+func Stale(artifacts []Artifact, analyzerVersion, autobuilderVersion, rulesVersion string, keepLogs int, inUse ...string) []Artifact {
+	protected := make(map[string]bool)
+	for _, path := range inUse {
+		protected[path] = true
+	}
+
+	var stale []Artifact
+	logs := 0
+	for _, artifact := range artifacts {
+		if protected[artifact.Path] {
+			continue
+		}
+		switch artifact.Type {
+		case ArtifactAnalyzer:
+			if artifact.Version != analyzerVersion {
+				stale = append(stale, artifact)
+			}
+		case ArtifactAutobuilder:
+			if artifact.Version != autobuilderVersion {
+				stale = append(stale, artifact)
+			}
+		case ArtifactRules:
+			if artifact.Version != rulesVersion {
+				stale = append(stale, artifact)
+			}
+		case ArtifactTemp:
+			if time.Since(artifact.ModTime) > staleTempAge {
+				stale = append(stale, artifact)
+			}
+		case ArtifactLog:
+			logs++
+			if logs > keepLogs {
+				stale = append(stale, artifact)
+			}
+		}
+	}
+	return stale
+}
+
+// Remove deletes the artifact from disk
+func Remove(artifact Artifact) error {
+	if artifact.Type == ArtifactModel {
+		return model_cache.Remove(artifact.Version)
+	}
+	return utils.RemoveCachedArtifact(artifact.Path)
+}
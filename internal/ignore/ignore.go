@@ -0,0 +1,153 @@
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/moby/patternmatcher"
+)
+
+// FileName is the file in the project root listing paths which are never sent to containers
+const FileName = ".seqraignore"
+
+// Matcher decides which project paths are excluded, paths are relative to the project root
+type Matcher struct {
+	patterns []string
+	pm       *patternmatcher.PatternMatcher
+}
+
+// Load reads .seqraignore from the project root, if there is one, and adds extra patterns to it.
+// Both use the gitignore syntax.
+func Load(absProjectRoot string, extra []string) (*Matcher, error) {
+	var lines []string
+	if absProjectRoot != "" {
+		f, err := os.Open(filepath.Join(absProjectRoot, FileName))
+		if err == nil {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			err = scanner.Err()
+			_ = f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", FileName, err)
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	lines = append(lines, extra...)
+
+	var patterns []string
+	for _, line := range lines {
+		if pattern, ok := convertPattern(line); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return New(patterns)
+}
+
+// New creates a matcher from patterns in the patternmatcher syntax
+func New(patterns []string) (*Matcher, error) {
+	pm, err := patternmatcher.New(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+	return &Matcher{patterns: patterns, pm: pm}, nil
+}
+
+// convertPattern translates a gitignore line into the patternmatcher syntax used by Docker archives
+func convertPattern(line string) (string, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", false
+	}
+
+	negated := strings.HasPrefix(line, "!")
+	line = strings.TrimPrefix(line, "!")
+	line = strings.TrimPrefix(line, `\`)
+
+	// Directories exclude their content anyway, so the trailing slash is dropped
+	line = strings.TrimSuffix(line, "/")
+	if line == "" {
+		return "", false
+	}
+
+	// A pattern without a slash matches at any depth, otherwise it is relative to the root
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+
+	if negated {
+		line = "!" + line
+	}
+	return line, true
+}
+
+// Empty tells whether nothing is excluded
+func (m *Matcher) Empty() bool {
+	return len(m.patterns) == 0
+}
+
+// Patterns returns patterns for archive.TarOptions.ExcludePatterns of an archive
+// which contains the project root under baseName, or relative to the project root if baseName is empty
+func (m *Matcher) Patterns(baseName string) []string {
+	if baseName == "" {
+		return m.patterns
+	}
+	var patterns []string
+	for _, pattern := range m.patterns {
+		if strings.HasPrefix(pattern, "!") {
+			patterns = append(patterns, "!"+baseName+"/"+strings.TrimPrefix(pattern, "!"))
+		} else {
+			patterns = append(patterns, baseName+"/"+pattern)
+		}
+	}
+	return patterns
+}
+
+// Matches tells whether the path, relative to the project root with slashes, is excluded
+func (m *Matcher) Matches(relPath string) bool {
+	if m.Empty() {
+		return false
+	}
+	matched, err := m.pm.MatchesOrParentMatches(filepath.FromSlash(strings.TrimPrefix(relPath, "/")))
+	return err == nil && matched
+}
+
+// ExcludedSize returns the number and the total size of excluded project files
+func (m *Matcher) ExcludedSize(absProjectRoot string) (files int, size int64, err error) {
+	if m.Empty() {
+		return 0, 0, nil
+	}
+	err = filepath.WalkDir(absProjectRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(absProjectRoot, path)
+		if err != nil {
+			return err
+		}
+		if !m.Matches(filepath.ToSlash(relPath)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files++
+		size += info.Size()
+		return nil
+	})
+	return files, size, err
+}
@@ -0,0 +1,76 @@
+package incremental
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+)
+
+// Changes between two snapshots of a project
+type Changes struct {
+	// Full is set when the changes can't be limited to modules, Reason tells why
+	Full   bool
+	Reason string
+	// Modules are the changed modules, Files the added, modified and removed files
+	Modules []string
+	Files   []string
+}
+
+// Empty tells whether nothing changed
+func (c Changes) Empty() bool {
+	return !c.Full && len(c.Files) == 0
+}
+
+// Compare finds files changed since the previous snapshot and the modules they belong to
+func Compare(previous, current *Snapshot) Changes {
+	var changes Changes
+	if !slices.Equal(previous.Modules, current.Modules) {
+		return Changes{Full: true, Reason: "modules were added or removed"}
+	}
+
+	for file, digest := range current.Files {
+		if previous.Files[file] != digest {
+			changes.Files = append(changes.Files, file)
+		}
+	}
+	for file := range previous.Files {
+		if _, ok := current.Files[file]; !ok {
+			changes.Files = append(changes.Files, file)
+		}
+	}
+	sort.Strings(changes.Files)
+
+	modules := make(map[string]bool)
+	for _, file := range changes.Files {
+		if buildFiles[path.Base(file)] {
+			return Changes{Full: true, Reason: fmt.Sprintf("build file %s changed", file), Files: changes.Files}
+		}
+		module := current.ModuleOf(file)
+		if module == "." {
+			return Changes{Full: true, Reason: fmt.Sprintf("%s isn't in a submodule", file), Files: changes.Files}
+		}
+		modules[module] = true
+	}
+	for module := range modules {
+		changes.Modules = append(changes.Modules, module)
+	}
+	sort.Strings(changes.Modules)
+	return changes
+}
+
+// ChangedClasses returns the classes declared by changed sources, now or in the previous snapshot
+func ChangedClasses(previous, current *Snapshot, changes Changes) []string {
+	seen := make(map[string]bool)
+	var classes []string
+	for _, file := range changes.Files {
+		for _, class := range append(current.Classes[file], previous.Classes[file]...) {
+			if !seen[class] {
+				seen[class] = true
+				classes = append(classes, class)
+			}
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
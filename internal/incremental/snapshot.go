@@ -0,0 +1,139 @@
+package incremental
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/seqrateam/seqra/internal/ignore"
+	"github.com/seqrateam/seqra/internal/model_cache"
+)
+
+// buildFiles define modules, a change of any of them may change the whole build
+var buildFiles = map[string]bool{
+	"pom.xml":             true,
+	"build.gradle":        true,
+	"build.gradle.kts":    true,
+	"settings.gradle":     true,
+	"settings.gradle.kts": true,
+	"gradle.properties":   true,
+}
+
+// Snapshot records the state of project files, paths are relative to the project root with slashes
+type Snapshot struct {
+	// Files maps files to their SHA-256 digests
+	Files map[string]string `json:"files"`
+	// Modules are directories with build files, "." is the root
+	Modules []string `json:"modules"`
+	// Classes maps Java and Kotlin sources to the classes they declare
+	Classes map[string][]string `json:"classes"`
+}
+
+// TakeSnapshot hashes the files of the project which aren't excluded
+func TakeSnapshot(absProjectRoot string, excludes *ignore.Matcher) (*Snapshot, error) {
+	snapshot := &Snapshot{Files: make(map[string]string), Classes: make(map[string][]string)}
+	modules := make(map[string]bool)
+
+	err := filepath.WalkDir(absProjectRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(absProjectRoot, p)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if d.IsDir() {
+			if p != absProjectRoot && (model_cache.IsIgnoredDir(p, d.Name()) || excludes.Matches(relPath)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || excludes.Matches(relPath) {
+			return nil
+		}
+
+		digest, err := fileDigest(p)
+		if err != nil {
+			return err
+		}
+		snapshot.Files[relPath] = digest
+		if buildFiles[d.Name()] {
+			modules[path.Dir(relPath)] = true
+		}
+		if classes := declaredClasses(p); len(classes) > 0 {
+			snapshot.Classes[relPath] = classes
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for module := range modules {
+		snapshot.Modules = append(snapshot.Modules, module)
+	}
+	sort.Strings(snapshot.Modules)
+	return snapshot, nil
+}
+
+// ModuleOf returns the innermost module containing the file
+func (s *Snapshot) ModuleOf(relPath string) string {
+	best := ""
+	for _, module := range s.Modules {
+		if module != "." && strings.HasPrefix(relPath, module+"/") && len(module) > len(best) {
+			best = module
+		}
+	}
+	if best == "" {
+		return "."
+	}
+	return best
+}
+
+func fileDigest(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// packagePattern matches the package declaration of a Java or Kotlin source
+var packagePattern = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)`)
+
+// declaredClasses returns the top-level class named after a Java or Kotlin source,
+// which with its nested classes is what a change of the file affects
+func declaredClasses(p string) []string {
+	ext := filepath.Ext(p)
+	if ext != ".java" && ext != ".kt" {
+		return nil
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+	name := strings.TrimSuffix(filepath.Base(p), ext)
+	if matches := packagePattern.FindSubmatch(data); matches != nil {
+		name = string(matches[1]) + "." + name
+	}
+	if ext == ".kt" {
+		// Top-level declarations of Kotlin files are compiled into <Name>Kt
+		return []string{name, name + "Kt"}
+	}
+	return []string{name}
+}
@@ -0,0 +1,105 @@
+package incremental
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+const (
+	stateFileName  = "state.json"
+	reportFileName = "report.sarif"
+)
+
+// State is what the previous scan of a project left for the next one
+type State struct {
+	// ModelKey is the model cache key of the analyzed project model
+	ModelKey string `json:"model_key"`
+	// AnalysisKey identifies the analyzer version and the rules the report was produced with
+	AnalysisKey string    `json:"analysis_key"`
+	Snapshot    *Snapshot `json:"snapshot"`
+}
+
+// Dir returns the directory keeping the state of the project compiled with compileType
+func Dir(absProjectRoot, compileType string) (string, error) {
+	seqraHomePath, err := utils.GetSeqraHome()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(absProjectRoot + "\x00" + compileType))
+	return filepath.Join(seqraHomePath, "incremental", hex.EncodeToString(hash[:])[:16]), nil
+}
+
+// Load reads the state of the previous scan, nil if there was none
+func Load(dir string) (*State, error) {
+	data, err := os.ReadFile(filepath.Join(dir, stateFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.Snapshot == nil {
+		return nil, nil
+	}
+	if _, err := os.Stat(ReportPath(dir)); err != nil {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// Save stores the state and the report of a finished scan
+func Save(dir string, state *State, absSarifReportPath string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := utils.CopyFile(absSarifReportPath, ReportPath(dir)); err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, stateFileName), data, 0644)
+}
+
+// ReportPath returns the SARIF report of the previous scan
+func ReportPath(dir string) string {
+	return filepath.Join(dir, reportFileName)
+}
+
+// AnalysisKey identifies the analyzer version and the content of the ruleset
+func AnalysisKey(analyzerVersion, absRuleSetPath string) (string, error) {
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s\x00%s\x00", analyzerVersion, absRuleSetPath)
+	err := filepath.WalkDir(absRuleSetPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		digest, err := fileDigest(p)
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(absRuleSetPath, p)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(hash, "%s\x00%s\x00", filepath.ToSlash(relPath), digest)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,141 @@
+package jdk
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Discover finds JDKs installed on the machine in the order of preference:
+// JAVA_HOME, java on PATH, Maven toolchains, SDKMAN and the system JDK directories
+// (Program Files on Windows).
+// A JDK found in several places is listed once.
+func Discover() []JDK {
+	var jdks []JDK
+	seen := make(map[string]bool)
+	add := func(home, source string) {
+		if home == "" {
+			return
+		}
+		resolved, err := filepath.EvalSymlinks(home)
+		if err != nil {
+			return
+		}
+		if seen[resolved] {
+			return
+		}
+		seen[resolved] = true
+		j, err := FromHome(home, source)
+		if err != nil {
+			logrus.Debugf("Skip JDK candidate %s: %s", home, err)
+			return
+		}
+		jdks = append(jdks, j)
+	}
+
+	add(os.Getenv("JAVA_HOME"), "JAVA_HOME")
+	add(pathJavaHome(), "PATH")
+	for _, home := range toolchainHomes() {
+		add(home, "toolchains.xml")
+	}
+	for _, home := range subdirs(sdkmanJavaDir()) {
+		add(home, "SDKMAN")
+	}
+	for _, home := range subdirs("/usr/lib/jvm") {
+		add(home, "/usr/lib/jvm")
+	}
+	if runtime.GOOS == "darwin" {
+		for _, home := range subdirs("/Library/Java/JavaVirtualMachines") {
+			add(filepath.Join(home, "Contents", "Home"), "/Library/Java/JavaVirtualMachines")
+		}
+	}
+	if programFiles := os.Getenv("ProgramFiles"); runtime.GOOS == "windows" && programFiles != "" {
+		for _, vendor := range []string{"Java", "Eclipse Adoptium", "Microsoft", "Zulu"} {
+			dir := filepath.Join(programFiles, vendor)
+			for _, home := range subdirs(dir) {
+				add(home, dir)
+			}
+		}
+	}
+	return jdks
+}
+
+// pathJavaHome returns the JDK home of java on PATH
+func pathJavaHome() string {
+	javaPath, err := exec.LookPath("java")
+	if err != nil {
+		return ""
+	}
+	javaPath, err = filepath.EvalSymlinks(javaPath)
+	if err != nil {
+		return ""
+	}
+	return filepath.Dir(filepath.Dir(javaPath))
+}
+
+func sdkmanJavaDir() string {
+	sdkmanDir := os.Getenv("SDKMAN_DIR")
+	if sdkmanDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		sdkmanDir = filepath.Join(home, ".sdkman")
+	}
+	return filepath.Join(sdkmanDir, "candidates", "java")
+}
+
+func subdirs(dir string) []string {
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for _, entry := range entries {
+		dirs = append(dirs, filepath.Join(dir, entry.Name()))
+	}
+	return dirs
+}
+
+type toolchains struct {
+	Toolchains []struct {
+		Type    string `xml:"type"`
+		JdkHome string `xml:"configuration>jdkHome"`
+	} `xml:"toolchain"`
+}
+
+// toolchainHomes returns JDK homes declared in ~/.m2/toolchains.xml
+func toolchainHomes() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".m2", "toolchains.xml"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		logrus.Debugf("Can't read Maven toolchains: %s", err)
+		return nil
+	}
+	var parsed toolchains
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		logrus.Debugf("Can't parse Maven toolchains: %s", err)
+		return nil
+	}
+	var homes []string
+	for _, toolchain := range parsed.Toolchains {
+		if toolchain.Type == "jdk" && toolchain.JdkHome != "" {
+			homes = append(homes, toolchain.JdkHome)
+		}
+	}
+	return homes
+}
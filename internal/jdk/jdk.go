@@ -0,0 +1,138 @@
+package jdk
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// JDK is a Java installation found on the machine
+type JDK struct {
+	Home    string
+	Version int
+	// Source tells where the JDK was found, e.g. JAVA_HOME or SDKMAN
+	Source string
+}
+
+// Java returns the java executable of the JDK
+func (j JDK) Java() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(j.Home, "bin", "java.exe")
+	}
+	return filepath.Join(j.Home, "bin", "java")
+}
+
+func (j JDK) String() string {
+	return fmt.Sprintf("%s (Java %d, %s)", j.Home, j.Version, j.Source)
+}
+
+// Env points JAVA_HOME and PATH to the JDK, so that build tools started with it use it too
+func (j JDK) Env() []string {
+	return []string{
+		"JAVA_HOME=" + j.Home,
+		"PATH=" + filepath.Join(j.Home, "bin") + string(os.PathListSeparator) + os.Getenv("PATH"),
+	}
+}
+
+// versionPattern matches the version in `java -version` output, e.g. `version "17.0.2"` or `version "1.8.0_292"`
+var versionPattern = regexp.MustCompile(`version "([^"]+)"`)
+
+// releaseVersionPattern matches the version in the release file of a JDK, e.g. `JAVA_VERSION="17.0.2"`
+var releaseVersionPattern = regexp.MustCompile(`(?m)^JAVA_VERSION="([^"]+)"`)
+
+// FromHome describes the JDK installed in home, source tells where it was found
+func FromHome(home, source string) (JDK, error) {
+	j := JDK{Home: filepath.Clean(home), Source: source}
+	if _, err := os.Stat(j.Java()); err != nil {
+		return JDK{}, fmt.Errorf("%s doesn't contain %s: %w", home, filepath.Base(j.Java()), err)
+	}
+
+	if release, err := os.ReadFile(filepath.Join(j.Home, "release")); err == nil {
+		if matches := releaseVersionPattern.FindStringSubmatch(string(release)); matches != nil {
+			if version, err := ParseVersion(matches[1]); err == nil {
+				j.Version = version
+				return j, nil
+			}
+		}
+	}
+
+	version, err := MajorVersion(j.Java())
+	if err != nil {
+		return JDK{}, err
+	}
+	j.Version = version
+	return j, nil
+}
+
+// MajorVersion runs `java -version` and returns the feature release, e.g. 8 for 1.8 and 17 for 17.0.2
+func MajorVersion(javaPath string) (int, error) {
+	out, err := exec.Command(javaPath, "-version").CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to run %s -version: %w", javaPath, err)
+	}
+	matches := versionPattern.FindStringSubmatch(string(out))
+	if matches == nil {
+		return 0, fmt.Errorf("unexpected java -version output: %q", out)
+	}
+	return ParseVersion(matches[1])
+}
+
+// numericVersionPattern matches the leading numbers of a version, e.g. "17.0" of "17.0.2+8" or "21" of "21-ea"
+var numericVersionPattern = regexp.MustCompile(`^(\d+)(?:\.(\d+))?`)
+
+// ParseVersion returns the feature release of a Java version like "1.8", "1.8.0_292", "17.0.2" or "VERSION_17"
+func ParseVersion(version string) (int, error) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "VERSION_")
+	matches := numericVersionPattern.FindStringSubmatch(strings.ReplaceAll(version, "_", "."))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid Java version %q", version)
+	}
+	major, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, err
+	}
+	if major == 1 && matches[2] != "" {
+		return strconv.Atoi(matches[2])
+	}
+	return major, nil
+}
+
+// Select picks the JDK for a project which requires the release: one of exactly that version,
+// otherwise the oldest newer one. Without a required release the first JDK wins.
+func Select(jdks []JDK, release int) (JDK, bool) {
+	if len(jdks) == 0 {
+		return JDK{}, false
+	}
+	if release == 0 {
+		return jdks[0], true
+	}
+	for _, j := range jdks {
+		if j.Version == release {
+			return j, true
+		}
+	}
+	return AtLeast(jdks, release, true)
+}
+
+// AtLeast returns the first JDK of version min or newer, or the oldest of them when oldest is set
+func AtLeast(jdks []JDK, min int, oldest bool) (JDK, bool) {
+	var found JDK
+	ok := false
+	for _, j := range jdks {
+		if j.Version < min {
+			continue
+		}
+		if !oldest {
+			return j, true
+		}
+		if !ok || j.Version < found.Version {
+			found, ok = j, true
+		}
+	}
+	return found, ok
+}
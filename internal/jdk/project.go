@@ -0,0 +1,134 @@
+package jdk
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Requirement is the Java release a project is built for, Source names the setting it was read from
+type Requirement struct {
+	Release int
+	Source  string
+}
+
+// ProjectRequirement reads the Java release declared by the Maven or Gradle build in the project root.
+// It returns a zero Requirement when the build doesn't declare one.
+func ProjectRequirement(projectRoot string) Requirement {
+	if requirement, ok := mavenRequirement(filepath.Join(projectRoot, "pom.xml")); ok {
+		return requirement
+	}
+	for _, name := range []string{"build.gradle", "build.gradle.kts"} {
+		if requirement, ok := gradleRequirement(filepath.Join(projectRoot, name)); ok {
+			return requirement
+		}
+	}
+	return Requirement{}
+}
+
+type pomProperties struct {
+	Entries []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+type pomCompilerConfiguration struct {
+	Release string `xml:"release"`
+	Target  string `xml:"target"`
+	Source  string `xml:"source"`
+}
+
+type pom struct {
+	Properties pomProperties `xml:"properties"`
+	Plugins    []struct {
+		ArtifactID    string                   `xml:"artifactId"`
+		Configuration pomCompilerConfiguration `xml:"configuration"`
+	} `xml:"build>plugins>plugin"`
+}
+
+// mavenPropertyPattern matches a property reference like ${java.version}
+var mavenPropertyPattern = regexp.MustCompile(`^\$\{([^}]+)}$`)
+
+func mavenRequirement(pomPath string) (Requirement, bool) {
+	data, err := os.ReadFile(pomPath)
+	if err != nil {
+		return Requirement{}, false
+	}
+	var parsed pom
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		logrus.Debugf("Can't parse %s: %s", pomPath, err)
+		return Requirement{}, false
+	}
+
+	properties := make(map[string]string)
+	for _, entry := range parsed.Properties.Entries {
+		properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	resolve := func(value string) string {
+		value = strings.TrimSpace(value)
+		if matches := mavenPropertyPattern.FindStringSubmatch(value); matches != nil {
+			return properties[matches[1]]
+		}
+		return value
+	}
+
+	var candidates []Requirement
+	add := func(value, source string) {
+		if value = resolve(value); value == "" {
+			return
+		}
+		if release, err := ParseVersion(value); err == nil {
+			candidates = append(candidates, Requirement{Release: release, Source: source})
+		}
+	}
+	for _, plugin := range parsed.Plugins {
+		if plugin.ArtifactID != "maven-compiler-plugin" {
+			continue
+		}
+		add(plugin.Configuration.Release, "maven-compiler-plugin release")
+		add(plugin.Configuration.Target, "maven-compiler-plugin target")
+		add(plugin.Configuration.Source, "maven-compiler-plugin source")
+	}
+	for _, property := range []string{"maven.compiler.release", "maven.compiler.target", "maven.compiler.source", "java.version"} {
+		add(properties[property], property)
+	}
+
+	if len(candidates) == 0 {
+		return Requirement{}, false
+	}
+	return candidates[0], true
+}
+
+// gradlePatterns match Java release declarations of Gradle builds, most specific first
+var gradlePatterns = []struct {
+	source  string
+	pattern *regexp.Regexp
+}{
+	{"Gradle toolchain", regexp.MustCompile(`JavaLanguageVersion\.of\(\s*(\d+)\s*\)`)},
+	{"Gradle toolchain", regexp.MustCompile(`jvmToolchain\(\s*(\d+)\s*\)`)},
+	{"Gradle options.release", regexp.MustCompile(`options\.release(?:\.set\(\s*|\s*=\s*)(\d+)`)},
+	{"Gradle targetCompatibility", regexp.MustCompile(`targetCompatibility\s*=\s*(?:JavaVersion\.)?['"]?(VERSION_[\d_]+|[\d.]+)`)},
+	{"Gradle sourceCompatibility", regexp.MustCompile(`sourceCompatibility\s*=\s*(?:JavaVersion\.)?['"]?(VERSION_[\d_]+|[\d.]+)`)},
+}
+
+func gradleRequirement(buildPath string) (Requirement, bool) {
+	data, err := os.ReadFile(buildPath)
+	if err != nil {
+		return Requirement{}, false
+	}
+	for _, gradlePattern := range gradlePatterns {
+		matches := gradlePattern.pattern.FindStringSubmatch(string(data))
+		if matches == nil {
+			continue
+		}
+		if release, err := ParseVersion(matches[1]); err == nil {
+			return Requirement{Release: release, Source: gradlePattern.source}, true
+		}
+	}
+	return Requirement{}, false
+}
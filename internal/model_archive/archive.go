@@ -0,0 +1,322 @@
+package model_archive
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/seqrateam/seqra/internal/project_model"
+	"github.com/seqrateam/seqra/internal/version"
+)
+
+// Suffix is the file name suffix of model archives
+const Suffix = ".tar.zst"
+
+// IsArchive tells whether path names a model archive rather than a project or a model directory
+func IsArchive(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && (strings.HasSuffix(path, Suffix) || strings.HasSuffix(path, ".tzst"))
+}
+
+// Create packs the model in modelDir with the manifest into archivePath. The archive gets a relocated
+// copy of project.yaml, modelDir itself is left unchanged. roots are the locations the model was written to,
+// see Relocate. It returns absolute paths the model still depends on.
+func Create(modelDir, archivePath string, roots []string, manifest Manifest) ([]string, error) {
+	relocation, err := Relocate(modelDir, append([]string{modelDir}, roots...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to relocate the model: %w", err)
+	}
+
+	manifest.SeqraVersion = version.Version
+	manifest.Created = time.Now().UTC()
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	generated := map[string][]byte{
+		project_model.FileName: relocation.ProjectYAML,
+		ManifestFileName:       manifestData,
+	}
+	temp := archivePath + ".temp"
+	if err := writeArchive(modelDir, temp, generated, relocation.External); err != nil {
+		_ = os.Remove(temp)
+		return nil, err
+	}
+	return relocation.Kept, os.Rename(temp, archivePath)
+}
+
+// writeArchive packs modelDir with generated entries replacing files of the same name,
+// and external files given by their entry names
+func writeArchive(modelDir, archivePath string, generated map[string][]byte, external map[string]string) (err error) {
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, out.Close())
+	}()
+
+	zw, err := zstd.NewWriter(out)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+
+	err = filepath.WalkDir(modelDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(modelDir, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		if _, ok := generated[filepath.ToSlash(relPath)]; ok {
+			return nil
+		}
+		// Models relocated in place by earlier versions already hold some external files
+		delete(external, filepath.ToSlash(relPath))
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if d.Type()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if !d.Type().IsRegular() {
+			return writeHeader(tw, header)
+		}
+		return writeFileEntry(tw, header, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(generated))
+	for name := range generated {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data := generated[name]
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now(), Typeflag: tar.TypeReg}
+		if err := writeHeader(tw, header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+
+	names = names[:0]
+	for name := range external {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		info, err := os.Stat(external[name])
+		if err != nil {
+			return err
+		}
+		header := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime(), Typeflag: tar.TypeReg}
+		if err := writeFileEntry(tw, header, external[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeHeader(tw *tar.Writer, header *tar.Header) error {
+	header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+	return tw.WriteHeader(header)
+}
+
+func writeFileEntry(tw *tar.Writer, header *tar.Header, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if err := writeHeader(tw, header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Extract unpacks a model archive into destDir and returns its manifest
+func Extract(archivePath, destDir string) (*Manifest, error) {
+	in, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	zr, err := zstd.NewReader(in)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	if err := extractTar(tar.NewReader(zr), destDir); err != nil {
+		return nil, err
+	}
+	manifest, err := ReadManifest(destDir)
+	if err != nil {
+		return nil, fmt.Errorf("not a seqra model archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// extractTar unpacks entries through an os.Root, so that no file is written outside of destDir.
+// Symlinks are created after all files, so that no file is written through them, and must
+// resolve to an existing path inside destDir.
+func extractTar(tr *tar.Reader, destDir string) error {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+	root, err := os.OpenRoot(destDir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	var symlinks []*tar.Header
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(destDir, filepath.FromSlash(header.Name))
+		name, ok := relativeTo(destDir, target)
+		if !ok {
+			return fmt.Errorf("archive entry %s is outside of the model", header.Name)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := mkdirAll(root, name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(root, tr, name, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			linkTarget := filepath.Join(filepath.Dir(target), header.Linkname)
+			if filepath.IsAbs(header.Linkname) || !isInside(destDir, linkTarget) {
+				return fmt.Errorf("archive symlink %s points outside of the model", header.Name)
+			}
+			symlinks = append(symlinks, header)
+		}
+	}
+
+	for _, header := range symlinks {
+		name, _ := relativeTo(destDir, filepath.Join(destDir, filepath.FromSlash(header.Name)))
+		if err := mkdirAll(root, path.Dir(name)); err != nil {
+			return err
+		}
+		// Symlinks are created by path, so their parent must not be reached through another symlink
+		if err := checkNoSymlinks(root, path.Dir(name)); err != nil {
+			return fmt.Errorf("archive symlink %s: %w", header.Name, err)
+		}
+		if err := os.Symlink(header.Linkname, filepath.Join(destDir, filepath.FromSlash(name))); err != nil {
+			return err
+		}
+	}
+	// Chains of symlinks may leave destDir even though each of them points inside lexically
+	for _, header := range symlinks {
+		name, _ := relativeTo(destDir, filepath.Join(destDir, filepath.FromSlash(header.Name)))
+		if _, err := root.Stat(name); err != nil {
+			return fmt.Errorf("archive symlink %s doesn't resolve inside of the model: %w", header.Name, err)
+		}
+	}
+	return nil
+}
+
+// mkdirAll creates the directory name with its parents inside root
+func mkdirAll(root *os.Root, name string) error {
+	current := ""
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		current = path.Join(current, part)
+		if err := root.Mkdir(current, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkNoSymlinks(root *os.Root, name string) error {
+	current := ""
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		current = path.Join(current, part)
+		info, err := root.Lstat(current)
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a symlink", current)
+		}
+	}
+	return nil
+}
+
+func extractFile(root *os.Root, r io.Reader, name string, mode os.FileMode) (err error) {
+	if err := mkdirAll(root, path.Dir(name)); err != nil {
+		return err
+	}
+	out, err := root.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, out.Close())
+	}()
+	_, err = io.Copy(out, r)
+	return err
+}
+
+func isInside(dir, p string) bool {
+	_, ok := relativeTo(dir, p)
+	return ok
+}
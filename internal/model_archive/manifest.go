@@ -0,0 +1,57 @@
+package model_archive
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ManifestFileName is the manifest in the root of a model archive
+const ManifestFileName = "seqra-model.json"
+
+// Manifest describes how the archived project model was created
+type Manifest struct {
+	SeqraVersion       string    `json:"seqra_version"`
+	AutobuilderVersion string    `json:"autobuilder_version"`
+	CompileType        string    `json:"compile_type"`
+	Created            time.Time `json:"created"`
+	// SourceCommit is the VCS revision of the compiled project, empty if unknown
+	SourceCommit string `json:"source_commit,omitempty"`
+	// ProjectRoot is where the project was compiled, it may not exist on the machine reading the archive
+	ProjectRoot string `json:"project_root,omitempty"`
+}
+
+// commitEnvVariables hold the revision in CI systems, used when the project isn't a git checkout
+var commitEnvVariables = []string{"GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT", "BUILD_SOURCEVERSION"}
+
+// SourceCommit returns the git revision of the project, or the one of the CI job
+func SourceCommit(projectRoot string) string {
+	if projectRoot != "" {
+		out, err := exec.Command("git", "-C", projectRoot, "rev-parse", "HEAD").Output()
+		if err == nil {
+			return strings.TrimSpace(string(out))
+		}
+	}
+	for _, variable := range commitEnvVariables {
+		if commit := os.Getenv(variable); commit != "" {
+			return commit
+		}
+	}
+	return ""
+}
+
+// ReadManifest reads the manifest of an extracted model archive
+func ReadManifest(modelDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(modelDir, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
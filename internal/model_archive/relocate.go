@@ -0,0 +1,112 @@
+package model_archive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/seqrateam/seqra/internal/project_model"
+)
+
+// externalDir keeps files the model referenced outside of its directory
+const externalDir = "external"
+
+// Relocation is project.yaml of a model with absolute paths rewritten
+type Relocation struct {
+	ProjectYAML []byte
+	// External maps entries under externalDir to the files outside of the model they were copied from
+	External map[string]string
+	// Kept are absolute paths the model depends on on another machine
+	Kept []string
+}
+
+// Relocate rewrites absolute paths in project.yaml of the model to paths relative to the model directory.
+// Paths under one of roots, the locations the model was written to, are made relative. Files outside
+// of the model, like dependencies of a native compile, are referenced under externalDir. Other absolute
+// paths are kept. The model directory isn't changed.
+func Relocate(modelDir string, roots []string) (*Relocation, error) {
+	yamlPath := filepath.Join(modelDir, project_model.FileName)
+	data, err := os.ReadFile(yamlPath)
+	if err != nil {
+		return nil, err
+	}
+	// A generic document keeps keys which the project model doesn't describe
+	var document yaml.MapSlice
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	r := relocator{roots: roots, copied: make(map[string]string), external: make(map[string]string)}
+	data, err = yaml.Marshal(r.value(document))
+	if err != nil {
+		return nil, err
+	}
+	return &Relocation{ProjectYAML: data, External: r.external, Kept: r.kept}, nil
+}
+
+type relocator struct {
+	roots    []string
+	copied   map[string]string
+	external map[string]string
+	kept     []string
+}
+
+func (r *relocator) value(v interface{}) interface{} {
+	switch value := v.(type) {
+	case yaml.MapSlice:
+		for i := range value {
+			value[i].Value = r.value(value[i].Value)
+		}
+		return value
+	case []interface{}:
+		for i := range value {
+			value[i] = r.value(value[i])
+		}
+		return value
+	case string:
+		return r.path(value)
+	}
+	return v
+}
+
+func (r *relocator) path(p string) string {
+	if !filepath.IsAbs(p) {
+		return p
+	}
+	for _, root := range r.roots {
+		if rel, ok := relativeTo(root, p); ok {
+			return rel
+		}
+	}
+
+	info, err := os.Stat(p)
+	if err != nil || !info.Mode().IsRegular() {
+		r.kept = append(r.kept, p)
+		return p
+	}
+	if copied, ok := r.copied[p]; ok {
+		return copied
+	}
+	// The directory name keeps files with the same name apart
+	hash := sha256.Sum256([]byte(filepath.Dir(p)))
+	rel := path.Join(externalDir, hex.EncodeToString(hash[:])[:12], filepath.Base(p))
+	r.external[rel] = p
+	r.copied[p] = rel
+	return rel
+}
+
+func relativeTo(root, p string) (string, bool) {
+	if root == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
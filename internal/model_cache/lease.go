@@ -0,0 +1,60 @@
+package model_cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/seqrateam/seqra/internal/failure"
+)
+
+const (
+	lockFileName = ".lock"
+	leaseInfix   = ".lease-"
+	tmpInfix     = ".tmp-"
+	// leaseTimeout is the age after which a lease is considered left by a crashed process
+	leaseTimeout = 24 * time.Hour
+)
+
+// lease marks the entry as used by this process until the returned release is called.
+// It has to be taken under the cache lock. The lease is also released when the run fails with logrus.Fatal.
+func lease(cachePath, key string) (func(), error) {
+	f, err := os.CreateTemp(cachePath, key+leaseInfix+"*")
+	if err != nil {
+		return nil, err
+	}
+	leasePath := f.Name()
+	_ = f.Close()
+	remove := func() {
+		_ = os.Remove(leasePath)
+	}
+	untrack := failure.TrackRelease("project model lease", leasePath, remove)
+	return func() {
+		untrack()
+		remove()
+	}, nil
+}
+
+// leased tells whether a process still uses the entry, stale leases are removed on the way
+func leased(cachePath, key string) bool {
+	leases, _ := filepath.Glob(filepath.Join(cachePath, key+leaseInfix+"*"))
+	used := false
+	for _, leasePath := range leases {
+		info, err := os.Stat(leasePath)
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) > leaseTimeout {
+			_ = os.Remove(leasePath)
+			continue
+		}
+		used = true
+	}
+	return used
+}
+
+// isEntryName tells whether a directory of the cache is a complete entry, not one being stored
+func isEntryName(name string) bool {
+	return !strings.Contains(name, tmpInfix) && !strings.HasPrefix(name, ".")
+}
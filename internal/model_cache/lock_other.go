@@ -0,0 +1,13 @@
+//go:build !unix
+
+package model_cache
+
+import "os"
+
+// lockCache only creates the cache directory where advisory locks aren't available, leases still protect entries
+func lockCache(cachePath string, _ bool) (func(), error) {
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		return nil, err
+	}
+	return func() {}, nil
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package model_cache
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockCache takes an advisory lock of the cache directory, shared or exclusive, and returns its release
+func lockCache(cachePath string, exclusive bool) (func(), error) {
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(cachePath, lockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
@@ -0,0 +1,328 @@
+package model_cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+const projectModelDirName = "project-model"
+
+// Build output directories are skipped only next to the build file that produces them,
+// so source packages which happen to be named "build" or "target" are still hashed
+var buildOutputDirs = map[string][]string{
+	"target": {"pom.xml"},
+	"build":  {"build.gradle", "build.gradle.kts", "settings.gradle", "settings.gradle.kts"},
+}
+
+var ignoredDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	".idea":        true,
+	".gradle":      true,
+	"node_modules": true,
+}
+
+// GetModelCachePath returns the directory containing cached project models
+func GetModelCachePath() (string, error) {
+	seqraHomePath, err := utils.GetSeqraHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(seqraHomePath, "models"), nil
+}
+
+// Key computes a cache key from the build files and sources of the project,
+// the project location, the compile type, the autobuilder version, the exclude patterns and the build hints.
+// Hashing stops when ctx is done.
+func Key(ctx context.Context, absProjectRoot, compileType, autobuilderVersion string, excludePatterns, buildFlags []string) (string, error) {
+	start := time.Now()
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%s\x00%s\x00%s\x00", autobuilderVersion, compileType, absProjectRoot)
+	for _, pattern := range excludePatterns {
+		_, _ = fmt.Fprintf(hash, "%s\x00", pattern)
+	}
+	_, _ = hash.Write([]byte{1})
+	for _, flag := range buildFlags {
+		_, _ = fmt.Fprintf(hash, "%s\x00", flag)
+	}
+
+	files := 0
+	err := filepath.WalkDir(absProjectRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != absProjectRoot && IsIgnoredDir(path, d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(absProjectRoot, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+
+		_, _ = fmt.Fprintf(hash, "%s\x00", filepath.ToSlash(relPath))
+		if _, err := io.Copy(hash, f); err != nil {
+			return err
+		}
+		_, _ = hash.Write([]byte{0})
+		files++
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash project: %w", err)
+	}
+
+	key := hex.EncodeToString(hash.Sum(nil))
+	logrus.Debugf("Project hash %s computed from %d files in %s", key, files, time.Since(start).Round(time.Millisecond))
+	return key, nil
+}
+
+// IsIgnoredDir tells whether a project directory holds VCS metadata, IDE settings or build outputs
+func IsIgnoredDir(path, name string) bool {
+	if ignoredDirs[name] {
+		return true
+	}
+	for _, buildFile := range buildOutputDirs[name] {
+		if _, err := os.Stat(filepath.Join(filepath.Dir(path), buildFile)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the cached project model for the key and marks it as recently used.
+// The model isn't evicted until the returned release is called.
+func Lookup(key string) (string, func(), bool) {
+	cachePath, err := GetModelCachePath()
+	if err != nil {
+		logrus.Debugf("Can't resolve model cache path: %s", err)
+		return "", nil, false
+	}
+	unlock, err := lockCache(cachePath, false)
+	if err != nil {
+		logrus.Debugf("Can't lock model cache: %s", err)
+		return "", nil, false
+	}
+	defer unlock()
+
+	entryPath := filepath.Join(cachePath, key)
+	modelPath := filepath.Join(entryPath, projectModelDirName)
+	if _, err := os.Stat(filepath.Join(modelPath, "project.yaml")); err != nil {
+		return "", nil, false
+	}
+	release, err := lease(cachePath, key)
+	if err != nil {
+		logrus.Debugf("Can't lease cached project model: %s", err)
+		return "", nil, false
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(entryPath, now, now); err != nil {
+		logrus.Debugf("Can't update model cache entry access time: %s", err)
+	}
+	return modelPath, release, true
+}
+
+// Store moves the compiled project model into the cache and returns its new location.
+// The model isn't evicted until the returned release is called.
+func Store(key, absProjectModelPath string) (string, func(), error) {
+	cachePath, err := GetModelCachePath()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := os.MkdirAll(cachePath, 0755); err != nil {
+		return "", nil, err
+	}
+
+	// Fill a temporary entry first, so that an interrupted store never looks like a valid entry
+	tmpEntryPath, err := os.MkdirTemp(cachePath, key+tmpInfix+"*")
+	if err != nil {
+		return "", nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpEntryPath)
+	}()
+
+	tmpModelPath := filepath.Join(tmpEntryPath, projectModelDirName)
+	if err := os.Rename(absProjectModelPath, tmpModelPath); err != nil {
+		logrus.Debugf("Can't move project model into cache, copy it instead: %s", err)
+		if err := utils.CopyDir(absProjectModelPath, tmpModelPath); err != nil {
+			return "", nil, fmt.Errorf("failed to copy project model into cache: %w", err)
+		}
+	}
+
+	unlock, err := lockCache(cachePath, true)
+	if err != nil {
+		return "", nil, err
+	}
+	defer unlock()
+
+	entryPath := filepath.Join(cachePath, key)
+	if leased(cachePath, key) {
+		// Another process uses the same model, which is equal to this one
+		release, err := lease(cachePath, key)
+		if err != nil {
+			return "", nil, err
+		}
+		return filepath.Join(entryPath, projectModelDirName), release, nil
+	}
+	if err := os.RemoveAll(entryPath); err != nil {
+		return "", nil, err
+	}
+	if err := os.Rename(tmpEntryPath, entryPath); err != nil {
+		return "", nil, err
+	}
+	release, err := lease(cachePath, key)
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Join(entryPath, projectModelDirName), release, nil
+}
+
+// Entry describes a cached project model
+type Entry struct {
+	Key      string
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+// List returns cached project models ordered from the least recently used
+func List() ([]Entry, error) {
+	cachePath, err := GetModelCachePath()
+	if err != nil {
+		return nil, err
+	}
+	return list(cachePath)
+}
+
+func list(cachePath string) ([]Entry, error) {
+	dirEntries, err := os.ReadDir(cachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || !isEntryName(dirEntry.Name()) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed by a concurrent run
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entryPath := filepath.Join(cachePath, dirEntry.Name())
+		size, err := utils.DirSize(entryPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Entry{
+			Key:      dirEntry.Name(),
+			Path:     entryPath,
+			Size:     size,
+			LastUsed: info.ModTime(),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.Before(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Evict removes the least recently used models until the cache fits into maxSize bytes.
+// The entry with keepKey and entries leased by running processes are never removed.
+func Evict(maxSize int64, keepKey string) error {
+	cachePath, err := GetModelCachePath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockCache(cachePath, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := list(cachePath)
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	for _, entry := range entries {
+		if total <= maxSize {
+			break
+		}
+		if entry.Key == keepKey || leased(cachePath, entry.Key) {
+			continue
+		}
+		logrus.Debugf("Evict cached project model: %s (%d bytes)", entry.Path, entry.Size)
+		if err := os.RemoveAll(entry.Path); err != nil {
+			return err
+		}
+		total -= entry.Size
+	}
+	return nil
+}
+
+// Remove deletes a cached project model unless a running process uses it
+func Remove(key string) error {
+	cachePath, err := GetModelCachePath()
+	if err != nil {
+		return err
+	}
+	unlock, err := lockCache(cachePath, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if leased(cachePath, key) {
+		return fmt.Errorf("project model %s is used by a running scan", key)
+	}
+	return os.RemoveAll(filepath.Join(cachePath, key))
+}
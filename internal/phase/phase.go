@@ -0,0 +1,85 @@
+package phase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	deadline      time.Time
+	deadlineLimit time.Duration
+)
+
+// SetDeadline bounds the whole run, phases begun later stop when it passes
+func SetDeadline(limit time.Duration) {
+	deadlineLimit = limit
+	deadline = time.Now().Add(limit)
+}
+
+// Remaining returns the time left until the deadline of the run, 0 if there is no deadline
+func Remaining() time.Duration {
+	if deadline.IsZero() {
+		return 0
+	}
+	return max(time.Until(deadline), time.Second)
+}
+
+// Phase is a step of the run bounded by its own timeout and the deadline of the run
+type Phase struct {
+	name    string
+	setting string
+	timeout time.Duration
+	// byDeadline is set when the deadline of the run comes before the phase timeout
+	byDeadline bool
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// Begin starts a phase limited by timeout, which is configured by setting. A zero timeout leaves only the deadline.
+func Begin(name, setting string, timeout time.Duration) *Phase {
+	p := &Phase{name: name, setting: setting, timeout: timeout}
+	var phaseDeadline time.Time
+	if timeout > 0 {
+		phaseDeadline = time.Now().Add(timeout)
+	}
+	if !deadline.IsZero() && (phaseDeadline.IsZero() || deadline.Before(phaseDeadline)) {
+		phaseDeadline = deadline
+		p.byDeadline = true
+	}
+	if phaseDeadline.IsZero() {
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+	} else {
+		p.ctx, p.cancel = context.WithDeadline(context.Background(), phaseDeadline)
+	}
+	return p
+}
+
+// Name returns the name of the phase
+func (p *Phase) Name() string {
+	return p.name
+}
+
+// Context is canceled when the phase runs out of time or ends
+func (p *Phase) Context() context.Context {
+	return p.ctx
+}
+
+// End releases the resources of the phase
+func (p *Phase) End() {
+	p.cancel()
+}
+
+// Expired tells whether the phase ran out of time
+func (p *Phase) Expired() bool {
+	return errors.Is(p.ctx.Err(), context.DeadlineExceeded)
+}
+
+// Error describes which limit stopped the phase
+func (p *Phase) Error() string {
+	if p.byDeadline {
+		return fmt.Sprintf("%s was stopped: the run exceeded its deadline of %s (deadline)", p.name, deadlineLimit)
+	}
+	return fmt.Sprintf("%s timed out after %s (%s)", p.name, p.timeout, p.setting)
+}
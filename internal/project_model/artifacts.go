@@ -0,0 +1,344 @@
+package project_model
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+const (
+	modulesDir      = "modules"
+	dependenciesDir = "dependencies"
+	sourcesSuffix   = "-sources.jar"
+)
+
+// appArchiveLayouts are the class and library directories of WARs and Spring Boot jars
+var appArchiveLayouts = []struct {
+	classes string
+	lib     string
+}{
+	{"WEB-INF/classes/", "WEB-INF/lib/"},
+	{"BOOT-INF/classes/", "BOOT-INF/lib/"},
+}
+
+// artifactsBuilder collects modules of a model built from pre-built artifacts
+type artifactsBuilder struct {
+	modelDir string
+	model    Model
+	names    map[string]int
+}
+
+// FromArtifacts builds a project model in modelDir from JARs, WARs and class directories found in artifactsDir.
+// Sources are taken from *-sources.jar next to the artifacts and from sourcePaths, which are source
+// directories or archives. Libraries of WARs and Spring Boot jars become dependencies.
+func FromArtifacts(artifactsDir string, sourcePaths []string, modelDir string) (*Model, error) {
+	b := &artifactsBuilder{
+		modelDir: modelDir,
+		model:    Model{SourceRoot: SourcesDir},
+		names:    make(map[string]int),
+	}
+	for _, dir := range []string{modulesDir, dependenciesDir, SourcesDir} {
+		if err := os.MkdirAll(filepath.Join(modelDir, dir), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	var archives []string
+	var classRoots []string
+	err := filepath.WalkDir(artifactsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		name := d.Name()
+		switch {
+		case strings.HasSuffix(name, sourcesSuffix):
+			sourcePaths = append(sourcePaths, p)
+		case strings.HasSuffix(name, ".jar"), strings.HasSuffix(name, ".war"):
+			archives = append(archives, p)
+		case strings.HasSuffix(name, ".class"):
+			for _, root := range classRoots {
+				if isUnder(root, p) {
+					return nil
+				}
+			}
+			root, err := classRoot(p)
+			if err != nil {
+				logrus.Warnf("Skip class file %s: %s", p, err)
+				return nil
+			}
+			classRoots = append(classRoots, root)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, root := range classRoots {
+		if err := b.addClassDir(artifactsDir, root); err != nil {
+			return nil, fmt.Errorf("failed to add classes of %s: %w", root, err)
+		}
+	}
+	for _, archive := range archives {
+		if err := b.addArchive(archive); err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", archive, err)
+		}
+	}
+	if len(b.model.Modules) == 0 {
+		return nil, fmt.Errorf("no JARs, WARs or class files found in %s", artifactsDir)
+	}
+
+	for _, sourcePath := range sourcePaths {
+		if err := b.addSources(sourcePath); err != nil {
+			return nil, fmt.Errorf("failed to add sources %s: %w", sourcePath, err)
+		}
+	}
+
+	if err := Write(modelDir, &b.model); err != nil {
+		return nil, err
+	}
+	return &b.model, nil
+}
+
+// classRoot returns the class path root of a class file by the class name stored in it
+func classRoot(classPath string) (string, error) {
+	data, err := os.ReadFile(classPath)
+	if err != nil {
+		return "", err
+	}
+	className, err := ClassName(data)
+	if err != nil {
+		return "", err
+	}
+	suffix := filepath.FromSlash(className) + ".class"
+	if !strings.HasSuffix(classPath, string(filepath.Separator)+suffix) {
+		return "", fmt.Errorf("class %s is not in its package directory", className)
+	}
+	return strings.TrimSuffix(classPath, string(filepath.Separator)+suffix), nil
+}
+
+// moduleName returns a unique name for a module in the model
+func (b *artifactsBuilder) moduleName(name string) string {
+	name = strings.NewReplacer("/", "-", "\\", "-", " ", "_").Replace(name)
+	if name == "" || name == "." {
+		name = "classes"
+	}
+	b.names[name]++
+	if b.names[name] > 1 {
+		name = fmt.Sprintf("%s-%d", name, b.names[name])
+	}
+	return name
+}
+
+func (b *artifactsBuilder) addClassDir(artifactsDir, root string) error {
+	relRoot, err := filepath.Rel(artifactsDir, root)
+	if err != nil {
+		return err
+	}
+	relClasses := path.Join(modulesDir, b.moduleName(filepath.ToSlash(relRoot)))
+	if err := utils.CopyDir(root, filepath.Join(b.modelDir, relClasses)); err != nil {
+		return err
+	}
+
+	packages := make(map[string]bool)
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(p, ".class") {
+			return err
+		}
+		relPath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		packages[packageOf(filepath.ToSlash(relPath))] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	b.addModule(relClasses, packages)
+	return nil
+}
+
+func (b *artifactsBuilder) addArchive(archivePath string) error {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	name := b.moduleName(strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath)))
+	for _, layout := range appArchiveLayouts {
+		if !hasPrefix(reader.File, layout.classes) {
+			continue
+		}
+		relClasses := path.Join(modulesDir, name)
+		packages, err := extractPrefix(reader.File, layout.classes, filepath.Join(b.modelDir, relClasses), isClass)
+		if err != nil {
+			return err
+		}
+		b.addModule(relClasses, packages)
+
+		relLib := path.Join(dependenciesDir, name)
+		libs, err := extractPrefix(reader.File, layout.lib, filepath.Join(b.modelDir, relLib), isJar)
+		if err != nil {
+			return err
+		}
+		// Sorted, so that the same archive always gives the same model
+		var sortedLibs []string
+		for lib := range libs {
+			sortedLibs = append(sortedLibs, lib)
+		}
+		sort.Strings(sortedLibs)
+		for _, lib := range sortedLibs {
+			b.model.Dependencies = append(b.model.Dependencies, path.Join(relLib, lib))
+		}
+		return nil
+	}
+
+	relJar := path.Join(modulesDir, name+".jar")
+	if err := utils.CopyFile(archivePath, filepath.Join(b.modelDir, relJar)); err != nil {
+		return err
+	}
+	packages := make(map[string]bool)
+	for _, file := range reader.File {
+		if isClass(file.Name) && !strings.HasPrefix(file.Name, "META-INF/") {
+			packages[packageOf(file.Name)] = true
+		}
+	}
+	b.addModule(relJar, packages)
+	return nil
+}
+
+func (b *artifactsBuilder) addModule(relClasses string, packages map[string]bool) {
+	b.model.Modules = append(b.model.Modules, Module{
+		SourceRoot: SourcesDir,
+		Packages:   topPackages(packages),
+		Classes:    []string{relClasses},
+	})
+}
+
+// addSources merges a source directory or a sources archive into the sources of the model
+func (b *artifactsBuilder) addSources(sourcePath string) error {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return err
+	}
+	destDir := filepath.Join(b.modelDir, SourcesDir)
+
+	if !info.IsDir() {
+		reader, err := zip.OpenReader(sourcePath)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = reader.Close()
+		}()
+		_, err = extractPrefix(reader.File, "", destDir, isSource)
+		return err
+	}
+
+	return filepath.WalkDir(sourcePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isSource(p) {
+			return err
+		}
+		relPath, err := filepath.Rel(sourcePath, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		return utils.CopyFile(p, target)
+	})
+}
+
+func hasPrefix(files []*zip.File, prefix string) bool {
+	for _, file := range files {
+		if strings.HasPrefix(file.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractPrefix extracts archive entries under prefix accepted by filter into destDir.
+// It returns the packages of extracted classes or the names of other extracted files.
+func extractPrefix(files []*zip.File, prefix, destDir string, filter func(name string) bool) (map[string]bool, error) {
+	extracted := make(map[string]bool)
+	for _, file := range files {
+		relName, ok := strings.CutPrefix(file.Name, prefix)
+		if !ok || relName == "" || strings.HasSuffix(relName, "/") || !filter(relName) {
+			continue
+		}
+		target := filepath.Join(destDir, filepath.FromSlash(relName))
+		if !isUnder(destDir, target) {
+			return nil, fmt.Errorf("archive entry %s is outside of the archive root", file.Name)
+		}
+		if err := extractFile(file, target); err != nil {
+			return nil, err
+		}
+		if isClass(relName) {
+			extracted[packageOf(relName)] = true
+		} else {
+			extracted[relName] = true
+		}
+	}
+	return extracted, nil
+}
+
+func extractFile(file *zip.File, target string) (err error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func isUnder(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func isClass(name string) bool {
+	return strings.HasSuffix(name, ".class") && !strings.HasSuffix(name, "module-info.class")
+}
+
+func isJar(name string) bool {
+	return strings.HasSuffix(name, ".jar")
+}
+
+func isSource(name string) bool {
+	return strings.HasSuffix(name, ".java") || strings.HasSuffix(name, ".kt")
+}
@@ -0,0 +1,98 @@
+package project_model
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const classFileMagic = 0xCAFEBABE
+
+// ClassName reads the binary name of the class, e.g. com/example/Foo, from a class file
+func ClassName(data []byte) (string, error) {
+	r := classReader{data: data}
+	if r.u4() != classFileMagic {
+		return "", errors.New("not a class file")
+	}
+	r.skip(4) // minor and major versions
+
+	count := int(r.u2())
+	utf8 := make(map[int]string)
+	classes := make(map[int]int)
+	for i := 1; i < count && r.err == nil; i++ {
+		tag := r.u1()
+		switch tag {
+		case 1: // Utf8
+			length := int(r.u2())
+			utf8[i] = string(r.bytes(length))
+		case 7: // Class
+			classes[i] = int(r.u2())
+		case 8, 16, 19, 20: // String, MethodType, Module, Package
+			r.skip(2)
+		case 15: // MethodHandle
+			r.skip(3)
+		case 3, 4, 9, 10, 11, 12, 17, 18: // Integer, Float, references, NameAndType, Dynamic, InvokeDynamic
+			r.skip(4)
+		case 5, 6: // Long and Double take two entries
+			r.skip(8)
+			i++
+		default:
+			return "", fmt.Errorf("unknown constant pool tag %d", tag)
+		}
+	}
+	r.skip(2) // access flags
+	thisClass := int(r.u2())
+	if r.err != nil {
+		return "", r.err
+	}
+
+	name, ok := utf8[classes[thisClass]]
+	if !ok {
+		return "", errors.New("invalid this_class entry")
+	}
+	return name, nil
+}
+
+type classReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *classReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if r.pos+n > len(r.data) {
+		r.err = errors.New("truncated class file")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *classReader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *classReader) u1() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *classReader) u2() uint16 {
+	if b := r.bytes(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *classReader) u4() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package project_model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FileName is the description of the model in the project model directory
+const FileName = "project.yaml"
+
+// SourcesDir is the directory of project sources in the project model directory
+const SourcesDir = "sources"
+
+type Module struct {
+	SourceRoot string     `yaml:"moduleSourceRoot"`
+	Packages   StringList `yaml:"packages"`
+	Classes    StringList `yaml:"moduleClasses"`
+}
+
+// Model is the project.yaml of a project model, relative paths are relative to the model directory
+type Model struct {
+	SourceRoot    string     `yaml:"sourceRoot"`
+	JavaToolchain string     `yaml:"javaToolchain,omitempty"`
+	Modules       []Module   `yaml:"modules"`
+	Dependencies  StringList `yaml:"dependencies"`
+}
+
+// StringList is a list which is also read from a single string
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*l = list
+		return nil
+	}
+	var single string
+	if err := unmarshal(&single); err != nil {
+		return err
+	}
+	*l = StringList{single}
+	return nil
+}
+
+// Read parses project.yaml of the model in modelDir. Unknown keys are ignored.
+func Read(modelDir string) (*Model, error) {
+	data, err := os.ReadFile(filepath.Join(modelDir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	var model Model
+	if err := yaml.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", FileName, err)
+	}
+	return &model, nil
+}
+
+// UnknownKeys returns top-level keys of project.yaml which Model doesn't describe, e.g. added by newer autobuilders
+func UnknownKeys(modelDir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(modelDir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	known := map[string]bool{"sourceRoot": true, "javaToolchain": true, "modules": true, "dependencies": true}
+	var unknown []string
+	for key := range raw {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// Resolve returns the absolute path of a path of the model
+func Resolve(modelDir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(modelDir, filepath.FromSlash(p))
+}
+
+// Classpath returns the absolute classes of all modules followed by the dependencies
+func (m *Model) Classpath(modelDir string) []string {
+	var classpath []string
+	for _, module := range m.Modules {
+		for _, classes := range module.Classes {
+			classpath = append(classpath, Resolve(modelDir, classes))
+		}
+	}
+	for _, dependency := range m.Dependencies {
+		classpath = append(classpath, Resolve(modelDir, dependency))
+	}
+	return classpath
+}
+
+// Write stores the model as project.yaml in modelDir
+func Write(modelDir string, model *Model) error {
+	data, err := yaml.Marshal(model)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(modelDir, FileName), data, 0644)
+}
+
+// topPackages collapses package names to the shortest prefixes, e.g. com.example and com.example.web give com.example
+func topPackages(packages map[string]bool) []string {
+	var sorted []string
+	for pkg := range packages {
+		if pkg != "" {
+			sorted = append(sorted, pkg)
+		}
+	}
+	sort.Strings(sorted)
+
+	var top []string
+	for _, pkg := range sorted {
+		if len(top) > 0 {
+			last := top[len(top)-1]
+			if pkg == last || strings.HasPrefix(pkg, last+".") {
+				continue
+			}
+		}
+		top = append(top, pkg)
+	}
+	return top
+}
+
+// packageOf returns the package of a binary class name like com/example/Foo$Bar
+func packageOf(className string) string {
+	i := strings.LastIndex(className, "/")
+	if i < 0 {
+		return ""
+	}
+	return strings.ReplaceAll(className[:i], "/", ".")
+}
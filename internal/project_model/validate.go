@@ -0,0 +1,71 @@
+package project_model
+
+import (
+	"fmt"
+	"os"
+)
+
+// Problem is an issue found in a project model, errors make the analysis fail or miss code
+type Problem struct {
+	Error   bool
+	Message string
+}
+
+// Validate checks that the model has modules and that the paths it references exist
+func Validate(modelDir string, model *Model) []Problem {
+	var problems []Problem
+	errorf := func(format string, args ...interface{}) {
+		problems = append(problems, Problem{Error: true, Message: fmt.Sprintf(format, args...)})
+	}
+	warnf := func(format string, args ...interface{}) {
+		problems = append(problems, Problem{Message: fmt.Sprintf(format, args...)})
+	}
+	exists := func(p string) bool {
+		_, err := os.Stat(Resolve(modelDir, p))
+		return err == nil
+	}
+
+	if model.SourceRoot != "" && !exists(model.SourceRoot) {
+		warnf("source root %s doesn't exist, findings won't have sources", model.SourceRoot)
+	}
+	if model.JavaToolchain != "" && !exists(model.JavaToolchain) {
+		warnf("Java toolchain %s doesn't exist", model.JavaToolchain)
+	}
+	if len(model.Modules) == 0 {
+		errorf("the model has no modules")
+	}
+	for i, module := range model.Modules {
+		name := ModuleName(module, i)
+		if len(module.Classes) == 0 {
+			errorf("module %s has no classes", name)
+		}
+		for _, classes := range module.Classes {
+			if !exists(classes) {
+				errorf("classes %s of module %s don't exist", classes, name)
+			}
+		}
+		if module.SourceRoot != "" && !exists(module.SourceRoot) {
+			warnf("source root %s of module %s doesn't exist", module.SourceRoot, name)
+		}
+		if len(module.Packages) == 0 {
+			warnf("module %s has no packages, none of its classes will be analyzed", name)
+		}
+	}
+	for _, dependency := range model.Dependencies {
+		if !exists(dependency) {
+			errorf("dependency %s doesn't exist", dependency)
+		}
+	}
+	return problems
+}
+
+// ModuleName returns a readable name of the i-th module
+func ModuleName(module Module, i int) string {
+	switch {
+	case module.SourceRoot != "" && module.SourceRoot != SourcesDir:
+		return module.SourceRoot
+	case len(module.Classes) > 0:
+		return module.Classes[0]
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
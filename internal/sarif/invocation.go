@@ -0,0 +1,128 @@
+package sarif
+
+import (
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxPrintedIncomplete limits the entry points and rules listed in the summary of an incomplete analysis
+const maxPrintedIncomplete = 20
+
+// Invocation describes how a run of the tool went
+type Invocation struct {
+	ExecutionSuccessful        bool            `json:"executionSuccessful"`
+	ExitCode                   *int            `json:"exitCode,omitempty"`
+	ToolExecutionNotifications []*Notification `json:"toolExecutionNotifications,omitempty"`
+}
+
+// Notification is a message of the tool about its execution, e.g. about an entry point it didn't finish
+type Notification struct {
+	Level          string                        `json:"level,omitempty"`
+	Message        *Message                      `json:"message"`
+	AssociatedRule *ReportingDescriptorReference `json:"associatedRule,omitempty"`
+	Locations      []*Location                   `json:"locations,omitempty"`
+}
+
+// ReportingDescriptorReference refers to a rule
+type ReportingDescriptorReference struct {
+	ID string `json:"id"`
+}
+
+// Incompleteness lists what an analysis didn't finish
+type Incompleteness struct {
+	Reasons []string
+	// EntryPoints are fully qualified names of methods whose analysis wasn't completed
+	EntryPoints []string
+	Rules       []string
+}
+
+// Incomplete returns what the analysis didn't finish, nil when all invocations were successful
+func (report *Report) Incomplete() *Incompleteness {
+	var incomplete *Incompleteness
+	for _, run := range report.Runs {
+		for _, invocation := range run.Invocations {
+			if invocation.ExecutionSuccessful {
+				continue
+			}
+			if incomplete == nil {
+				incomplete = &Incompleteness{}
+			}
+			for _, notification := range invocation.ToolExecutionNotifications {
+				incomplete.addNotification(notification)
+			}
+		}
+	}
+	if incomplete != nil && len(incomplete.Reasons) == 0 {
+		incomplete.Reasons = []string{"the analyzer reported an unsuccessful execution"}
+	}
+	return incomplete
+}
+
+func (incomplete *Incompleteness) addNotification(notification *Notification) {
+	if notification.AssociatedRule != nil {
+		incomplete.Rules = appendNew(incomplete.Rules, notification.AssociatedRule.ID)
+	}
+	entryPoint := false
+	for _, location := range notification.Locations {
+		for _, logical := range location.LogicalLocations {
+			if logical.FullyQualifiedName != nil {
+				incomplete.EntryPoints = appendNew(incomplete.EntryPoints, *logical.FullyQualifiedName)
+				entryPoint = true
+			}
+		}
+	}
+	// Notifications about single entry points or rules are listed, not repeated as reasons
+	if !entryPoint && notification.AssociatedRule == nil && notification.Message != nil {
+		incomplete.Reasons = appendNew(incomplete.Reasons, notification.Message.Text)
+	}
+}
+
+func appendNew(values []string, value string) []string {
+	if value == "" || slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// MarkIncomplete marks all runs of the report as unsuccessful with a notification telling why
+func (report *Report) MarkIncomplete(reason string, exitCode int) {
+	notification := &Notification{Level: "error", Message: &Message{Text: reason}}
+	for _, run := range report.Runs {
+		if len(run.Invocations) == 0 {
+			run.Invocations = []*Invocation{{}}
+		}
+		for _, invocation := range run.Invocations {
+			invocation.ExecutionSuccessful = false
+			if exitCode != 0 {
+				invocation.ExitCode = &exitCode
+			}
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, notification)
+		}
+	}
+}
+
+// Print logs what the analysis didn't finish
+func (incomplete *Incompleteness) Print() {
+	logrus.Info()
+	logrus.Warn("Analysis is incomplete, some findings may be missing:")
+	for _, reason := range incomplete.Reasons {
+		logrus.Warnf("  %s", reason)
+	}
+	printLimited("Not completed entry points", incomplete.EntryPoints)
+	printLimited("Not completed rules", incomplete.Rules)
+}
+
+func printLimited(title string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	logrus.Warnf("%s: %d", title, len(values))
+	for i, value := range values {
+		if i == maxPrintedIncomplete {
+			logrus.Warnf("  ... and %d more", len(values)-maxPrintedIncomplete)
+			break
+		}
+		logrus.Warnf("  %s", value)
+	}
+}
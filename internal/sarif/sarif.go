@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -22,6 +24,7 @@ type Report struct {
 type Run struct {
 	Tool               *Tool                       `json:"tool"`
 	Results            []*Result                   `json:"results,omitempty"`
+	Invocations        []*Invocation               `json:"invocations,omitempty"`
 	OriginalUriBaseIds map[string]ArtifactLocation `json:"originalUriBaseIds,omitempty"`
 }
 
@@ -75,6 +78,10 @@ type Result struct {
 	RuleId    string      `json:"ruleId"`
 	Locations []*Location `json:"locations,omitempty"`
 	CodeFlows []*CodeFlow `json:"codeFlows,omitempty"`
+	// RelatedLocations and fingerprints are kept, so that incremental scans can match results
+	RelatedLocations    []*Location       `json:"relatedLocations,omitempty"`
+	Fingerprints        map[string]string `json:"fingerprints,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
 }
 
 // Message contains the text of a result message
@@ -84,7 +91,7 @@ type Message struct {
 
 // Location represents a location in source code
 type Location struct {
-	PhysicalLocation *PhysicalLocation  `json:"physicalLocation"`
+	PhysicalLocation *PhysicalLocation  `json:"physicalLocation,omitempty"`
 	LogicalLocations []*LogicalLocation `json:"logicalLocations,omitempty"`
 	Message          *Message           `json:"message,omitempty"`
 }
@@ -121,6 +128,8 @@ type Summary struct {
 	TotalRulesRun       int
 	TotalRulesTriggered int
 	FindingsByLevel     map[string]int
+	// Incomplete is set when the analysis didn't finish
+	Incomplete bool
 }
 
 // Parse parses SARIF data using standard json package
@@ -161,10 +170,25 @@ func GenerateSummary(report *Report) Summary {
 
 	summary.TotalRulesTriggered += len(rulesTriggered)
 	summary.TotalRulesRun += len(rulesRun)
+	summary.Incomplete = report.Incomplete() != nil
 
 	return summary
 }
 
+// Add accumulates findings of another summary, used to aggregate several reports
+func (summary *Summary) Add(other Summary) {
+	if summary.FindingsByLevel == nil {
+		summary.FindingsByLevel = make(map[string]int)
+	}
+	summary.TotalFindings += other.TotalFindings
+	summary.TotalRulesRun += other.TotalRulesRun
+	summary.TotalRulesTriggered += other.TotalRulesTriggered
+	summary.Incomplete = summary.Incomplete || other.Incomplete
+	for level, count := range other.FindingsByLevel {
+		summary.FindingsByLevel[level] += count
+	}
+}
+
 // PrintSummary prints a human-readable summary of the SARIF report
 func (report *Report) PrintSummary() {
 	summary := GenerateSummary(report)
@@ -266,6 +290,138 @@ func updateLocation(location *Location) {
 	}
 }
 
+// RemoveResults drops results whose primary location is in an excluded file and returns their number
+func (report *Report) RemoveResults(excluded func(uri string) bool) int {
+	removed := 0
+	for _, run := range report.Runs {
+		var kept []*Result
+		for _, result := range run.Results {
+			if len(result.Locations) > 0 {
+				location := result.Locations[0].PhysicalLocation
+				if location != nil && location.ArtifactLocation != nil && excluded(location.ArtifactLocation.URI) {
+					removed++
+					continue
+				}
+			}
+			kept = append(kept, result)
+		}
+		run.Results = kept
+	}
+	return removed
+}
+
+// MergeResults adds results of the previous report which don't touch a reanalyzed file in any of their
+// locations, code flows included, and which the analyzer didn't report again, together with the rules
+// they refer to. It returns the number of merged results, and false without merging anything when a
+// previous result has a location without a file, so that it can't tell whether the result is affected.
+func (report *Report) MergeResults(previous *Report, reanalyzed func(uri string) bool) (int, bool) {
+	if len(report.Runs) == 0 || len(previous.Runs) == 0 {
+		return 0, true
+	}
+	run := report.Runs[0]
+	knownRules := make(map[string]bool)
+	if run.Tool != nil && run.Tool.Driver != nil {
+		for _, rule := range run.Tool.Driver.Rules {
+			if rule.ID != nil {
+				knownRules[*rule.ID] = true
+			}
+		}
+	}
+	known := make(map[string]bool)
+	for _, current := range report.Runs {
+		for _, result := range current.Results {
+			known[result.key()] = true
+		}
+	}
+
+	var results []*Result
+	for _, previousRun := range previous.Runs {
+		for _, result := range previousRun.Results {
+			uris, ok := result.uris()
+			if !ok {
+				return 0, false
+			}
+			if slices.ContainsFunc(uris, reanalyzed) || known[result.key()] {
+				continue
+			}
+			results = append(results, result)
+		}
+	}
+	run.Results = append(run.Results, results...)
+
+	for _, previousRun := range previous.Runs {
+		if previousRun.Tool == nil || previousRun.Tool.Driver == nil || run.Tool == nil || run.Tool.Driver == nil {
+			continue
+		}
+		for _, rule := range previousRun.Tool.Driver.Rules {
+			if rule.ID != nil && !knownRules[*rule.ID] {
+				knownRules[*rule.ID] = true
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+			}
+		}
+	}
+	return len(results), true
+}
+
+// key identifies a result across reports by its fingerprints, or by the rule and the primary location
+func (result *Result) key() string {
+	for _, fingerprints := range []map[string]string{result.Fingerprints, result.PartialFingerprints} {
+		if len(fingerprints) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(fingerprints))
+		for name := range fingerprints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var key strings.Builder
+		key.WriteString(result.RuleId)
+		for _, name := range names {
+			fmt.Fprintf(&key, "|%s=%s", name, fingerprints[name])
+		}
+		return key.String()
+	}
+
+	key := result.RuleId
+	if len(result.Locations) > 0 && result.Locations[0].PhysicalLocation != nil {
+		location := result.Locations[0].PhysicalLocation
+		if location.ArtifactLocation != nil {
+			key += "|" + location.ArtifactLocation.URI
+		}
+		if location.Region != nil {
+			key += fmt.Sprintf(":%d", location.Region.StartLine)
+			if location.Region.StartColumn != nil {
+				key += fmt.Sprintf(":%d", *location.Region.StartColumn)
+			}
+		}
+	}
+	return key
+}
+
+// uris returns the files of all locations of the result, false if one of them has no file
+func (result *Result) uris() ([]string, bool) {
+	locations := append(slices.Clone(result.Locations), result.RelatedLocations...)
+	for _, codeFlow := range result.CodeFlows {
+		for _, threadFlow := range codeFlow.ThreadFlows {
+			for i := range threadFlow.Locations {
+				locations = append(locations, &threadFlow.Locations[i].Location)
+			}
+		}
+	}
+
+	var uris []string
+	for _, location := range locations {
+		if location == nil || location.PhysicalLocation == nil || location.PhysicalLocation.ArtifactLocation == nil {
+			return nil, false
+		}
+		uris = append(uris, location.PhysicalLocation.ArtifactLocation.URI)
+	}
+	if len(uris) == 0 {
+		return nil, false
+	}
+	return uris, true
+}
+
 // UpdateURIInfo updates URI information in the SARIF report
 func (report *Report) UpdateURIInfo(absProjectPath string) {
 	for _, run := range report.Runs {
@@ -308,6 +464,13 @@ func (report *Report) UpdateRuleId(absRulesPath, userRulesPath string) {
 		for _, result := range run.Results {
 			result.RuleId = semgrep.GetSemgrepRuleId(result.RuleId, absRulesPath, ruleStart)
 		}
+		for _, invocation := range run.Invocations {
+			for _, notification := range invocation.ToolExecutionNotifications {
+				if notification.AssociatedRule != nil {
+					notification.AssociatedRule.ID = semgrep.GetSemgrepRuleId(notification.AssociatedRule.ID, absRulesPath, ruleStart)
+				}
+			}
+		}
 		if run.Tool != nil && run.Tool.Driver != nil {
 			for _, rules := range run.Tool.Driver.Rules {
 				*rules.ID = semgrep.GetSemgrepRuleId(*rules.ID, absRulesPath, ruleStart)
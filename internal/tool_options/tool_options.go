@@ -0,0 +1,68 @@
+package tool_options
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/utils"
+)
+
+// Help is the --help output of a version of the autobuilder or the analyzer, it tells which options the version accepts
+type Help struct {
+	text string
+}
+
+// Load returns the help of the tool version, run prints it. It is run once per version, the output is kept in seqra home.
+// knownOption is an option every version accepts, a help without it can't tell which options are supported.
+func Load(tool, version, knownOption string, run func() ([]byte, error)) (*Help, error) {
+	helpPath, err := utils.GetToolHelpPath(tool, version)
+	if err != nil {
+		return nil, err
+	}
+	if text, err := os.ReadFile(helpPath); err == nil {
+		return &Help{text: string(text)}, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	logrus.Debugf("Read options of %s %s", tool, version)
+	text, err := run()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s --help: %w", tool, err)
+	}
+	help := &Help{text: string(text)}
+	if !help.Supports(knownOption) {
+		return nil, fmt.Errorf("%s --help doesn't list its options: %q", tool, strings.TrimSpace(string(text)))
+	}
+	if err := os.WriteFile(helpPath, text, 0644); err != nil {
+		logrus.Debugf("Failed to keep options of %s %s: %s", tool, version, err)
+	}
+	return help, nil
+}
+
+// Supports tells whether the help lists the option, e.g. --build-system
+func (help *Help) Supports(option string) bool {
+	pattern := regexp.MustCompile(`(^|[^\w-])` + regexp.QuoteMeta(option) + `([^\w-]|$)`)
+	return pattern.MatchString(help.text)
+}
+
+// Missing returns the options of flags which the help doesn't list, each once
+func (help *Help) Missing(flags []string) []string {
+	var missing []string
+	for _, flag := range flags {
+		option, _, _ := strings.Cut(flag, "=")
+		if !strings.HasPrefix(option, "--") || help.Supports(option) {
+			continue
+		}
+		if !slices.Contains(missing, option) {
+			missing = append(missing, option)
+		}
+	}
+	return missing
+}
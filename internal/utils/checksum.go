@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const digestSidecarSuffix = ".sha256"
+
+// FileSHA256 returns the hex encoded SHA-256 digest of the file
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DirSHA256 returns the hex encoded SHA-256 digest of relative paths, symlink targets and contents of all files under path
+func DirSHA256(path string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(path, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(path, filePath)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(hash, "L%s\x00%s\x00", filepath.ToSlash(relPath), link)
+		case d.Type().IsRegular():
+			f, err := os.Open(filePath)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = f.Close()
+			}()
+			_, _ = fmt.Fprintf(hash, "F%s\x00", filepath.ToSlash(relPath))
+			if _, err := io.Copy(hash, f); err != nil {
+				return err
+			}
+			_, _ = hash.Write([]byte{0})
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DigestSidecarPath returns the path of the file storing the verified digest of a cached artifact
+func DigestSidecarPath(path string) string {
+	return path + digestSidecarSuffix
+}
+
+// IsDigestSidecar reports whether the file name belongs to a digest sidecar
+func IsDigestSidecar(name string) bool {
+	return strings.HasSuffix(name, digestSidecarSuffix)
+}
+
+func writeDigestSidecar(path, digest string) error {
+	return os.WriteFile(DigestSidecarPath(path), []byte(digest+"\n"), 0644)
+}
+
+func readDigestSidecar(path string) (string, error) {
+	data, err := os.ReadFile(DigestSidecarPath(path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// VerifyCachedFile checks the cached file against the digest recorded when it was downloaded
+// and against pinnedDigest if it isn't empty
+func VerifyCachedFile(path, pinnedDigest string) error {
+	digest, err := FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if pinnedDigest != "" && !strings.EqualFold(digest, pinnedDigest) {
+		return fmt.Errorf("digest mismatch for %s: expected %s, got %s", path, pinnedDigest, digest)
+	}
+	return verifySidecar(path, digest)
+}
+
+// VerifyCachedDir checks the unpacked directory against the digest recorded when it was unpacked
+func VerifyCachedDir(path string) error {
+	digest, err := DirSHA256(path)
+	if err != nil {
+		return err
+	}
+	return verifySidecar(path, digest)
+}
+
+func verifySidecar(path, digest string) error {
+	expected, err := readDigestSidecar(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Artifacts cached by older versions have no recorded digest
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(expected, digest) {
+		return fmt.Errorf("digest mismatch for %s: expected %s, got %s", path, expected, digest)
+	}
+	return nil
+}
+
+// RemoveCachedArtifact removes the cached file or directory together with its digest sidecar
+func RemoveCachedArtifact(path string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return err
+	}
+	return RemoveIfExists(DigestSidecarPath(path))
+}
+
+// parseChecksums parses the output of sha256sum: "<digest>  <name>" per line
+func parseChecksums(data []byte) map[string]string {
+	digests := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		digests[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return digests
+}
+
+// verifySignature checks an ed25519 signature (raw or base64 encoded) of data
+// with a base64 encoded public key
+func verifySignature(data, signature []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key: expected %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+
+	if len(signature) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+		if err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+		signature = decoded
+	}
+
+	if !ed25519.Verify(key, data, signature) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+func checkDigest(artifactName, expected, actual string) error {
+	if expected == "" {
+		return nil
+	}
+	if !strings.EqualFold(expected, actual) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", artifactName, expected, actual)
+	}
+	return nil
+}
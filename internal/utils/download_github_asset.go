@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -11,101 +13,202 @@ import (
 	"path"
 	"strings"
 
-	"github.com/google/go-github/v72/github"
 	"github.com/sirupsen/logrus"
+
+	"github.com/seqrateam/seqra/internal/globals"
+	"github.com/seqrateam/seqra/internal/phase"
 )
 
+// ErrOffline is returned for downloads attempted while network access is disabled
+var ErrOffline = errors.New("network access is disabled by --offline, import the artifacts with \"seqra bundle import\"")
+
+func readAsset(ctx context.Context, source releaseSource, assetName string) ([]byte, error) {
+	rc, _, err := source.openAsset(ctx, assetName)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	return io.ReadAll(rc)
+}
+
+// resolveExpectedDigest returns the expected SHA-256 digest of the artifact.
+// A digest pinned in globals is preferred, otherwise the release checksums asset is used
+// (its signature is checked when a public key is configured).
+// An empty digest means that no digest is known and only the size can be verified.
+func resolveExpectedDigest(ctx context.Context, source releaseSource, repository, releaseTag, artifactName string) (string, error) {
+	if digest, ok := globals.PinnedArtifactDigest(repository, releaseTag, artifactName); ok {
+		logrus.Debugf("Use pinned digest for %s: %s", artifactName, digest)
+		return digest, nil
+	}
+
+	verify := globals.Config.Verify
+	noDigest := func(reason string) (string, error) {
+		if verify.RequireChecksum {
+			return "", fmt.Errorf("can't verify %s: %s", artifactName, reason)
+		}
+		warnUnverified(globals.ArtifactDigestKey(repository, releaseTag, artifactName), reason)
+		return "", nil
+	}
+
+	checksums, err := readAsset(ctx, source, globals.ChecksumsAssetName)
+	if errors.Is(err, ErrAssetNotFound) {
+		if verify.PublicKey != "" {
+			return "", fmt.Errorf("can't verify signature of %s: release has no %s", artifactName, globals.ChecksumsAssetName)
+		}
+		return noDigest("release has no " + globals.ChecksumsAssetName)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", globals.ChecksumsAssetName, err)
+	}
+
+	if verify.PublicKey != "" {
+		signature, err := readAsset(ctx, source, globals.ChecksumsAssetName+".sig")
+		if errors.Is(err, ErrAssetNotFound) {
+			return "", fmt.Errorf("can't verify signature of %s: release has no %s.sig", artifactName, globals.ChecksumsAssetName)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to download %s.sig: %w", globals.ChecksumsAssetName, err)
+		}
+		if err := verifySignature(checksums, signature, verify.PublicKey); err != nil {
+			return "", fmt.Errorf("%s of %s %s: %w", globals.ChecksumsAssetName, repository, releaseTag, err)
+		}
+		logrus.Debugf("Signature of %s verified", globals.ChecksumsAssetName)
+	}
+
+	digest, ok := parseChecksums(checksums)[artifactName]
+	if !ok {
+		return noDigest(globals.ChecksumsAssetName + " has no entry for it")
+	}
+	return digest, nil
+}
+
+// warnUnverified tells that an artifact is used without any check of its content
+func warnUnverified(artifact, reason string) {
+	logrus.Warnf("%s is NOT verified: %s", artifact, reason)
+	logrus.Warn("Pin its SHA-256 digest in verify.digests, or set verify.require_checksum to refuse unverified artifacts")
+}
+
+// DownloadGithubReleaseAsset downloads a release asset within pull.timeout and the deadline of the run
 func DownloadGithubReleaseAsset(owner, repository, releaseTag, assetName, assetPath, token string) error {
-	var client *github.Client
-	if token == "" {
-		client = github.NewClient(nil)
-	} else {
-		client = github.NewClient(nil).WithAuthToken(token)
+	download := phase.Begin("Download of "+assetName, "pull.timeout", globals.Config.Pull.Timeout)
+	defer download.End()
+	err := downloadGithubReleaseAsset(download.Context(), owner, repository, releaseTag, assetName, assetPath, token)
+	if err != nil && download.Expired() {
+		return errors.New(download.Error())
 	}
+	return err
+}
 
-	ctx := context.Background()
-	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repository, releaseTag)
+func downloadGithubReleaseAsset(ctx context.Context, owner, repository, releaseTag, assetName, assetPath, token string) error {
+	source, err := openReleaseSource(ctx, owner, repository, releaseTag, token)
 	if err != nil {
 		return err
 	}
 
-	assets := release.Assets
-
-	for assetId := range assets {
-		if *assets[assetId].Name == assetName {
-			asset := assets[assetId]
-			expectedSize := int64(asset.GetSize())
-			rc, _, err := client.Repositories.DownloadReleaseAsset(ctx, owner, repository, asset.GetID(), client.Client())
-			if err != nil {
-				return err
-			}
-			defer func() {
-				_ = rc.Close()
-			}()
-
-			tmpPath := assetPath + ".temp"
-
-			logrus.Debugf("Download asset to: %s", tmpPath)
-			tmpFile, err := os.Create(tmpPath)
-			if err != nil {
-				return err
-			}
-			defer func() {
-				err = tmpFile.Close()
-				_ = os.Remove(tmpFile.Name())
-			}()
-
-			written, err := io.Copy(tmpFile, rc)
-			if err != nil {
-				return err
-			}
-
-			if written != expectedSize {
-				return fmt.Errorf("file size mismatch: expected %d bytes, got %d bytes", expectedSize, written)
-			}
-
-			logrus.Debugf("Move asset to: %s", assetPath)
-			if err := os.Rename(tmpFile.Name(), assetPath); err != nil {
-				return err
-			}
-
-			return nil
-		}
+	rc, expectedSize, err := source.openAsset(ctx, assetName)
+	if errors.Is(err, ErrAssetNotFound) {
+		return fmt.Errorf("can't find %s in release assets: %w", assetName, err)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	expectedDigest, err := resolveExpectedDigest(ctx, source, repository, releaseTag, assetName)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := assetPath + ".temp"
+
+	logrus.Debugf("Download asset to: %s", tmpPath)
+	tmpFile, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+	}()
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmpFile, hash), rc)
+	if err != nil {
+		return err
+	}
+
+	if expectedSize >= 0 && written != expectedSize {
+		return fmt.Errorf("file size mismatch: expected %d bytes, got %d bytes", expectedSize, written)
+	}
+
+	digest := hex.EncodeToString(hash.Sum(nil))
+	if err := checkDigest(assetName, expectedDigest, digest); err != nil {
+		return err
+	}
+	if err := writeDigestSidecar(assetPath, digest); err != nil {
+		return err
+	}
+
+	logrus.Debugf("Move asset to: %s", assetPath)
+	if err := os.Rename(tmpFile.Name(), assetPath); err != nil {
+		return err
 	}
-	return errors.New("can't find artifact in release assets")
+
+	return nil
 }
 
+// DownloadAndUnpackGithubReleaseArchive downloads the sources of a release within pull.timeout and the deadline of the run
 func DownloadAndUnpackGithubReleaseArchive(owner, repository, releaseTag, assetPath, token string) error {
-	var client *github.Client
-	if token == "" {
-		client = github.NewClient(nil)
-	} else {
-		client = github.NewClient(nil).WithAuthToken(token)
+	download := phase.Begin("Download of "+repository+" "+releaseTag, "pull.timeout", globals.Config.Pull.Timeout)
+	defer download.End()
+	err := downloadAndUnpackGithubReleaseArchive(download.Context(), owner, repository, releaseTag, assetPath, token)
+	if err != nil && download.Expired() {
+		return errors.New(download.Error())
 	}
+	return err
+}
 
-	ctx := context.Background()
-	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repository, releaseTag)
+func downloadAndUnpackGithubReleaseArchive(ctx context.Context, owner, repository, releaseTag, assetPath, token string) error {
+	source, err := openReleaseSource(ctx, owner, repository, releaseTag, token)
 	if err != nil {
 		return err
 	}
 
-	archiveURL := release.TarballURL
-
-	resp, err := client.Client().Get(*archiveURL)
+	archiveName := fmt.Sprintf("%s-%s.tar.gz", repository, releaseTag)
+	archive, published, err := source.openSourceArchive(ctx, archiveName)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		_ = resp.Body.Close()
+		_ = archive.Close()
 	}()
 
+	var expectedDigest string
+	if published {
+		expectedDigest, err = resolveExpectedDigest(ctx, source, repository, releaseTag, archiveName)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Digests can only be published for the archive asset, a generated tarball isn't byte-stable
+		if globals.Config.Verify.RequireChecksum {
+			return fmt.Errorf("can't verify %s: the release has no %s asset", repository, archiveName)
+		}
+		warnUnverified(globals.ArtifactDigestKey(repository, releaseTag, archiveName), "the release has no such asset, the sources tarball GitHub generates isn't byte-stable")
+	}
+
 	tmpPath := assetPath + ".temp"
 
 	out, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
-	if _, err := io.Copy(out, resp.Body); err != nil {
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), archive); err != nil {
 		_ = out.Close()
 		return err
 	}
@@ -113,6 +216,11 @@ func DownloadAndUnpackGithubReleaseArchive(owner, repository, releaseTag, assetP
 		_ = out.Close()
 	}()
 
+	if err := checkDigest(archiveName, expectedDigest, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
 	f, err := os.Open(tmpPath)
 	if err != nil {
 		return err
@@ -172,9 +280,18 @@ func DownloadAndUnpackGithubReleaseArchive(owner, repository, releaseTag, assetP
 
 	tr2 := tar.NewReader(gz2)
 
-	if err := ExtractTar(tr2, basePath, assetPath, true); err != nil {
+	// Unpack next to the destination and move it in place only when it is complete
+	unpackPath := assetPath + ".unpack.temp"
+	if err := os.RemoveAll(unpackPath); err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(unpackPath)
+	}()
+
+	if err := ExtractTar(tr2, basePath, unpackPath, true); err != nil {
 		return err
 	}
 
-	return nil
+	return installUnpackedDir(unpackPath, assetPath)
 }
@@ -1,6 +1,11 @@
 package utils
 
-import "regexp"
+import (
+	"regexp"
+	"strings"
+
+	"github.com/seqrateam/seqra/internal/globals"
+)
 
 func GetImageLink(version, path string) string {
 	imageRefRegex := regexp.MustCompile(`^([a-zA-Z0-9.-/]*)?[a-zA-Z0-9._-]+:[a-zA-Z0-9._-]+$`)
@@ -9,3 +14,84 @@ func GetImageLink(version, path string) string {
 	}
 	return path + ":" + version
 }
+
+// GetPinnedImageLink returns a reference which pulls the image by digest when the digest is known
+func GetPinnedImageLink(version, digest, path string) string {
+	imageLink := GetImageLink(version, path)
+	if digest == "" {
+		return imageLink
+	}
+	return GetImageRepository(imageLink) + "@" + digest
+}
+
+// GetImageRepository strips the tag and the digest from the image reference
+func GetImageRepository(imageLink string) string {
+	if i := strings.Index(imageLink, "@"); i != -1 {
+		imageLink = imageLink[:i]
+	}
+	if i := strings.LastIndex(imageLink, ":"); i > strings.LastIndex(imageLink, "/") {
+		imageLink = imageLink[:i]
+	}
+	return imageLink
+}
+
+// GetImageDigest returns the digest of a reference pinned by digest, or an empty string
+func GetImageDigest(imageLink string) string {
+	if i := strings.Index(imageLink, "@"); i != -1 {
+		return imageLink[i+1:]
+	}
+	return ""
+}
+
+// dockerHubHost is the key Docker config uses for credentials of Docker Hub
+const dockerHubHost = "https://index.docker.io/v1/"
+
+// GetImageRegistryHost returns the registry host of the image reference
+func GetImageRegistryHost(imageLink string) string {
+	i := strings.Index(imageLink, "/")
+	if i == -1 {
+		return dockerHubHost
+	}
+	host := imageLink[:i]
+	if strings.ContainsAny(host, ".:") || host == "localhost" {
+		return host
+	}
+	return dockerHubHost
+}
+
+// GetMirroredRepository moves a seqra image repository to registry.mirror when it is configured
+func GetMirroredRepository(repository string) string {
+	mirror := strings.TrimSuffix(globals.Config.Registry.Mirror, "/")
+	if mirror == "" {
+		return repository
+	}
+	return mirror + strings.TrimPrefix(repository, globals.GithubDockerHost+"/"+globals.RepoOwner)
+}
+
+// GetAnalyzerRepository returns the analyzer image repository
+func GetAnalyzerRepository() string {
+	return GetMirroredRepository(globals.AnalyzerDocker)
+}
+
+// GetAutobuilderRepository returns the autobuilder image repository
+func GetAutobuilderRepository() string {
+	return GetMirroredRepository(globals.AutobuilderDocker)
+}
+
+// GetAnalyzerImageLink returns the configured analyzer image reference
+func GetAnalyzerImageLink() string {
+	digest := globals.Config.Analyzer.Digest
+	if digest == "" && globals.Config.Analyzer.Version == globals.AnalyzerBindVersion {
+		digest = globals.AnalyzerBindDigest
+	}
+	return GetPinnedImageLink(globals.Config.Analyzer.Version, digest, GetAnalyzerRepository())
+}
+
+// GetAutobuilderImageLink returns the configured autobuilder image reference
+func GetAutobuilderImageLink() string {
+	digest := globals.Config.Autobuilder.Digest
+	if digest == "" && globals.Config.Autobuilder.Version == globals.AutobuilderBindVersion {
+		digest = globals.AutobuilderBindDigest
+	}
+	return GetPinnedImageLink(globals.Config.Autobuilder.Version, digest, GetAutobuilderRepository())
+}
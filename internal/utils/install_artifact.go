@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"os"
+)
+
+// InstallCachedFile copies a verified file into seqra home and records its digest.
+// The destination is replaced atomically.
+func InstallCachedFile(srcPath, destPath string) error {
+	digest, err := FileSHA256(srcPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	tmpPath := destPath + ".temp"
+	if err := copyFile(srcPath, tmpPath, info.Mode().Perm()); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := writeDigestSidecar(destPath, digest); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, destPath)
+}
+
+// InstallCachedDir copies a verified directory into seqra home and records its digest.
+// The destination is replaced only when the copy is complete.
+func InstallCachedDir(srcDir, destPath string) error {
+	unpackPath := destPath + ".unpack.temp"
+	if err := os.RemoveAll(unpackPath); err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(unpackPath)
+	}()
+
+	if err := CopyDir(srcDir, unpackPath); err != nil {
+		return err
+	}
+	return installUnpackedDir(unpackPath, destPath)
+}
+
+func installUnpackedDir(unpackPath, destPath string) error {
+	dirDigest, err := DirSHA256(unpackPath)
+	if err != nil {
+		return err
+	}
+	if err := RemoveCachedArtifact(destPath); err != nil {
+		return err
+	}
+	if err := writeDigestSidecar(destPath, dirDigest); err != nil {
+		return err
+	}
+	return os.Rename(unpackPath, destPath)
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"strings"
+)
+
+const javaToolOptionsEnv = "JAVA_TOOL_OPTIONS"
+
+// JavaOptions returns JVM options for the maximum heap and additional space separated options
+func JavaOptions(heap, extra string) []string {
+	var options []string
+	if heap != "" {
+		options = append(options, "-Xmx"+heap)
+	}
+	return append(options, strings.Fields(extra)...)
+}
+
+// AppendJavaToolOptions adds options to JAVA_TOOL_OPTIONS in env, which every JVM reads on start
+func AppendJavaToolOptions(env []string, options []string) []string {
+	if len(options) == 0 {
+		return env
+	}
+	for i, variable := range env {
+		if value, ok := strings.CutPrefix(variable, javaToolOptionsEnv+"="); ok {
+			env[i] = javaToolOptionsEnv + "=" + strings.TrimSpace(value+" "+strings.Join(options, " "))
+			return env
+		}
+	}
+	return append(env, javaToolOptionsEnv+"="+strings.Join(options, " "))
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// percentPattern matches a progress value like "45%" or "12.5 %"
+var percentPattern = regexp.MustCompile(`(\d{1,3}(?:\.\d+)?)\s*%`)
+
+// ParseProgress extracts the phase and the percent from a progress line of the analyzer,
+// e.g. "2025-09-01 12:00:00 INFO  [ifds] Analysis: 45%" gives "Analysis" and 45
+func ParseProgress(line string) (phase string, percent float64, ok bool) {
+	matches := percentPattern.FindAllStringSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+	match := matches[len(matches)-1]
+	percent, err := strconv.ParseFloat(line[match[2]:match[3]], 64)
+	if err != nil || percent > 100 {
+		return "", 0, false
+	}
+
+	phase = strings.TrimRight(line[:match[0]], " \t:=-")
+	for _, separator := range []string{"] ", " - ", ": ", "\t"} {
+		if i := strings.LastIndex(phase, separator); i >= 0 {
+			phase = phase[i+len(separator):]
+		}
+	}
+	phase = strings.TrimSpace(phase)
+	if len(phase) > 40 {
+		phase = phase[:40]
+	}
+	return phase, percent, true
+}
+
+// ProgressLine shows the progress of a container task in one console line which is redrawn in place
+type ProgressLine struct {
+	task    string
+	start   time.Time
+	enabled bool
+
+	mu      sync.Mutex
+	phase   string
+	percent float64
+	known   bool
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewProgressLine starts the indicator. It is disabled in quiet mode, when stdout isn't a terminal,
+// and when container lines are printed to the console anyway at debug verbosity.
+func NewProgressLine(task string, quiet bool) *ProgressLine {
+	p := &ProgressLine{
+		task:    task,
+		start:   time.Now(),
+		enabled: !quiet && term.IsTerminal(int(os.Stdout.Fd())) && !consoleShowsDebug(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if !p.enabled {
+		close(p.done)
+		return p
+	}
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			p.draw()
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				fmt.Print("\r\033[K")
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// Update takes the progress from a container output line, other lines are ignored
+func (p *ProgressLine) Update(line string) {
+	phase, percent, ok := ParseProgress(line)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if phase != "" {
+		p.phase = phase
+	}
+	p.percent = percent
+	p.known = true
+}
+
+// Stop clears the indicator
+func (p *ProgressLine) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
+
+func (p *ProgressLine) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	elapsed := time.Since(p.start).Round(time.Second)
+	status := p.task
+	if p.known {
+		if p.phase != "" {
+			status += ": " + p.phase
+		}
+		status += fmt.Sprintf(" %.0f%%", p.percent)
+	}
+	fmt.Printf("\r\033[K%s (%s)", status, elapsed)
+}
@@ -18,9 +18,15 @@ import (
 )
 
 var (
-	logFile *os.File
+	logFile      *os.File
+	consoleLevel = logrus.InfoLevel
 )
 
+// consoleShowsDebug tells whether debug messages are printed to the console
+func consoleShowsDebug() bool {
+	return consoleLevel >= logrus.DebugLevel
+}
+
 // OpenLogFile creates and returns a file for logging at the specified path.
 // It creates the directory structure if it doesn't exist.
 // The file handle is stored in a global variable and can be closed with CloseLogFile().
@@ -127,10 +133,11 @@ func (f *blockTextFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 // 'out' is typically the log file writer. Logs will go to both the console and 'out'.
 func SetUpLogs(out io.Writer, level string) error {
 	// Parse log level
-	consoleLevel, err := logrus.ParseLevel(level)
+	parsedLevel, err := logrus.ParseLevel(level)
 	if err != nil {
 		return err
 	}
+	consoleLevel = parsedLevel
 
 	// File formatter (with per-line timestamp/level/etc.)
 	fileFormatter := &blockTextFormatter{
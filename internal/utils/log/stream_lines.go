@@ -0,0 +1,27 @@
+package log
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxLineSize bounds a single output line, longer lines are split
+const maxLineSize = 1024 * 1024
+
+// StreamLines writes the output of a container or a subprocess to entry at debug level line by line
+// and feeds it to the progress indicator until r ends. The rest of r is drained on a read error.
+func StreamLines(r io.Reader, entry *logrus.Entry, progress *ProgressLine) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		entry.Debug(line)
+		progress.Update(line)
+	}
+	if err := scanner.Err(); err != nil {
+		entry.Debugf("Error reading output: %v", err)
+		_, _ = io.Copy(io.Discard, r)
+	}
+}
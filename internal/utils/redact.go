@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces secrets in logs and debug bundles
+const Redacted = "<redacted>"
+
+// RedactURL hides credentials embedded into URLs, e.g. of a mirror or a proxy.
+// Proxy URLs without a scheme, like user:password@host:port, are handled as well.
+func RedactURL(value string) string {
+	withScheme := value
+	if !strings.Contains(value, "://") {
+		withScheme = "http://" + value
+	}
+	u, err := url.Parse(withScheme)
+	if err != nil || u.User == nil {
+		return value
+	}
+	u.User = nil
+	scheme, rest, _ := strings.Cut(u.String(), "://")
+	redactedValue := scheme + "://" + Redacted + "@" + rest
+	return strings.TrimPrefix(redactedValue, strings.TrimSuffix(withScheme, value))
+}
+
+// RedactEnv returns a copy of NAME=value variables with credentials in URL values hidden
+func RedactEnv(env []string) []string {
+	result := make([]string, len(env))
+	for i, variable := range env {
+		name, value, ok := strings.Cut(variable, "=")
+		if !ok {
+			result[i] = variable
+			continue
+		}
+		result[i] = name + "=" + RedactURL(value)
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v72/github"
+
+	"github.com/seqrateam/seqra/internal/globals"
+)
+
+// ErrAssetNotFound is returned for assets the release doesn't have
+var ErrAssetNotFound = errors.New("asset not found")
+
+// releaseSource provides assets of a single release, either from GitHub or from a mirror
+type releaseSource interface {
+	// openAsset returns the asset content and its size, -1 if the size is unknown
+	openAsset(ctx context.Context, assetName string) (io.ReadCloser, int64, error)
+	// openSourceArchive returns the gzipped tarball of the release sources named archiveName.
+	// published is false when it isn't a release asset but an archive generated on request,
+	// whose bytes aren't stable and can't be checked against a digest.
+	openSourceArchive(ctx context.Context, archiveName string) (rc io.ReadCloser, published bool, err error)
+}
+
+// openReleaseSource uses artifacts.base_url when it is configured and the GitHub API otherwise
+func openReleaseSource(ctx context.Context, owner, repository, releaseTag, token string) (releaseSource, error) {
+	if globals.Config.Offline {
+		return nil, ErrOffline
+	}
+
+	httpClient, err := NewHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if baseURL := globals.Config.Artifacts.BaseURL; baseURL != "" {
+		return &mirroredRelease{
+			httpClient: httpClient,
+			baseURL:    strings.TrimSuffix(baseURL, "/"),
+			repository: repository,
+			releaseTag: releaseTag,
+		}, nil
+	}
+
+	client := newGithubClient(httpClient, token)
+	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repository, releaseTag)
+	if err != nil {
+		return nil, err
+	}
+	return &githubRelease{client: client, owner: owner, repository: repository, release: release}, nil
+}
+
+func newGithubClient(httpClient *http.Client, token string) *github.Client {
+	if token == "" {
+		return github.NewClient(httpClient)
+	}
+	return github.NewClient(httpClient).WithAuthToken(token)
+}
+
+type githubRelease struct {
+	client     *github.Client
+	owner      string
+	repository string
+	release    *github.RepositoryRelease
+}
+
+func (r *githubRelease) openAsset(ctx context.Context, assetName string) (io.ReadCloser, int64, error) {
+	for _, asset := range r.release.Assets {
+		if asset.GetName() != assetName {
+			continue
+		}
+		rc, _, err := r.client.Repositories.DownloadReleaseAsset(ctx, r.owner, r.repository, asset.GetID(), r.client.Client())
+		if err != nil {
+			return nil, 0, err
+		}
+		return rc, int64(asset.GetSize()), nil
+	}
+	var names []string
+	for _, asset := range r.release.Assets {
+		names = append(names, asset.GetName())
+	}
+	return nil, 0, fmt.Errorf("%w, release %s of %s has %s", ErrAssetNotFound, r.release.GetTagName(), r.repository, strings.Join(names, ", "))
+}
+
+func (r *githubRelease) openSourceArchive(ctx context.Context, archiveName string) (io.ReadCloser, bool, error) {
+	rc, _, err := r.openAsset(ctx, archiveName)
+	if err == nil {
+		return rc, true, nil
+	}
+	if !errors.Is(err, ErrAssetNotFound) {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.release.GetTarballURL(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := r.client.Client().Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, false, fmt.Errorf("failed to download %s: %s", r.release.GetTarballURL(), resp.Status)
+	}
+	return resp.Body, false, nil
+}
+
+// mirroredRelease serves the release assets from a generic HTTP server laid out as
+// <base_url>/<repository>/<tag>/<asset>, the sources archive is an asset too
+type mirroredRelease struct {
+	httpClient *http.Client
+	baseURL    string
+	repository string
+	releaseTag string
+}
+
+func (r *mirroredRelease) assetURL(assetName string) string {
+	return r.baseURL + "/" + r.repository + "/" + r.releaseTag + "/" + assetName
+}
+
+func (r *mirroredRelease) openAsset(ctx context.Context, assetName string) (io.ReadCloser, int64, error) {
+	url := r.assetURL(assetName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if globals.Config.Artifacts.Username != "" {
+		req.SetBasicAuth(globals.Config.Artifacts.Username, globals.Config.Artifacts.Password)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, resp.ContentLength, nil
+	case http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, 0, ErrAssetNotFound
+	default:
+		_ = resp.Body.Close()
+		return nil, 0, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+}
+
+func (r *mirroredRelease) openSourceArchive(ctx context.Context, archiveName string) (io.ReadCloser, bool, error) {
+	rc, _, err := r.openAsset(ctx, archiveName)
+	return rc, true, err
+}
@@ -30,6 +30,15 @@ func GetAutobuilderJarPath(version string) (string, error) {
 	return autobuilderJar, nil
 }
 
+func GetAnalyzerJarPath(version string) (string, error) {
+	seqraHomePath, err := GetSeqraHome()
+	if err != nil {
+		return "", err
+	}
+	analyzerJar := seqraHomePath + "/analyzer_" + version + ".jar"
+	return analyzerJar, nil
+}
+
 func GetRulesPath(version string) (string, error) {
 	seqraHomePath, err := GetSeqraHome()
 	if err != nil {
@@ -38,3 +47,16 @@ func GetRulesPath(version string) (string, error) {
 	rulesPath := seqraHomePath + "/rules_" + version
 	return rulesPath, nil
 }
+
+// GetToolHelpPath returns where the --help output of a tool version is kept
+func GetToolHelpPath(tool, version string) (string, error) {
+	seqraHomePath, err := GetSeqraHome()
+	if err != nil {
+		return "", err
+	}
+	helpDir := seqraHomePath + "/options"
+	if err := os.MkdirAll(helpDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	return helpDir + "/" + tool + "_" + version + ".txt", nil
+}
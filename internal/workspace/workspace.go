@@ -0,0 +1,123 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ManifestFileName is the default name of a workspace manifest
+const ManifestFileName = "seqra.workspace.yaml"
+
+// Project describes a single project of the workspace
+type Project struct {
+	Name        string        `mapstructure:"name"`
+	Path        string        `mapstructure:"path"`
+	Ruleset     string        `mapstructure:"ruleset"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	CompileType string        `mapstructure:"compile_type"`
+}
+
+// Defaults are applied to every project which doesn't override the value
+type Defaults struct {
+	Ruleset     string        `mapstructure:"ruleset"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	CompileType string        `mapstructure:"compile_type"`
+}
+
+// Manifest represents the content of seqra.workspace.yaml
+type Manifest struct {
+	Concurrency int       `mapstructure:"concurrency"`
+	Defaults    Defaults  `mapstructure:"defaults"`
+	Projects    []Project `mapstructure:"projects"`
+
+	// Dir is the absolute path of the directory containing the manifest
+	Dir string `mapstructure:"-"`
+}
+
+// ResolveManifestPath accepts either a manifest file or a directory containing ManifestFileName
+func ResolveManifestPath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		absPath = filepath.Join(absPath, ManifestFileName)
+		if _, err := os.Stat(absPath); err != nil {
+			return "", err
+		}
+	}
+	return absPath, nil
+}
+
+// Load reads the manifest, applies defaults and resolves project paths relative to the manifest directory
+func Load(absManifestPath string) (*Manifest, error) {
+	v := viper.New()
+	v.SetConfigFile(absManifestPath)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read workspace manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := v.Unmarshal(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace manifest: %w", err)
+	}
+	manifest.Dir = filepath.Dir(absManifestPath)
+
+	if len(manifest.Projects) == 0 {
+		return nil, errors.New("workspace manifest doesn't contain any projects")
+	}
+
+	names := make(map[string]bool)
+	for i := range manifest.Projects {
+		project := &manifest.Projects[i]
+		if project.Path == "" {
+			return nil, fmt.Errorf("project #%d has no path", i+1)
+		}
+		if !filepath.IsAbs(project.Path) {
+			project.Path = filepath.Join(manifest.Dir, project.Path)
+		}
+		project.Path = filepath.Clean(project.Path)
+
+		if project.Name == "" {
+			project.Name = filepath.Base(project.Path)
+		}
+		// The name becomes the file name of the report and the log of the project
+		if project.Name == "." || project.Name == ".." || strings.ContainsAny(project.Name, `/\`) {
+			return nil, fmt.Errorf("invalid project name %q, it must not contain path separators or be \".\" or \"..\"", project.Name)
+		}
+		if names[project.Name] {
+			return nil, fmt.Errorf("duplicate project name %q, set a unique name for each project", project.Name)
+		}
+		names[project.Name] = true
+
+		if project.Ruleset == "" {
+			project.Ruleset = manifest.Defaults.Ruleset
+		}
+		if project.Ruleset != "" && !filepath.IsAbs(project.Ruleset) {
+			project.Ruleset = filepath.Join(manifest.Dir, project.Ruleset)
+		}
+		if project.Timeout == 0 {
+			project.Timeout = manifest.Defaults.Timeout
+		}
+		if project.CompileType == "" {
+			project.CompileType = manifest.Defaults.CompileType
+		}
+		switch project.CompileType {
+		case "", "docker", "native":
+		default:
+			return nil, fmt.Errorf("project %q: compile_type must be one of \"docker\", \"native\"", project.Name)
+		}
+	}
+
+	return &manifest, nil
+}